@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// follows symlinks to the real file, so a dotfile symlink is not replaced by a normal file
+var resolveSymlinks = func(filename string) (string, error) {
+	var lstater, canLstat = AppFs.(afero.Lstater)
+	var reader, canReadlink = AppFs.(afero.LinkReader)
+	if !canLstat || !canReadlink {
+		return filename, nil
+	}
+	// the same limit as linux
+	for hops := 0; hops < 40; hops++ {
+		var info, _, err = lstater.LstatIfPossible(filename)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			return filename, nil
+		}
+		var target, readErr = reader.ReadlinkIfPossible(filename)
+		if readErr != nil {
+			return "", readErr
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(filename), target)
+		}
+		filename = target
+	}
+	return "", fmt.Errorf("too many levels of symbolic links in %s", filename)
+}
+
+/*
+replaces the content of a file without leaving a half-written file behind:
+the data goes to a temporary file next to it, which is synced and then renamed over the old file
+the new file keeps the mode and the owner of the old one, a new file gets its folder created too
+*/
+var writeFileAtomic = func(filename string, data []byte) error {
+	var target, resolveErr = resolveSymlinks(filename)
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	var mode fs.FileMode = 0644
+	var info, statErr = AppFs.Stat(target)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return statErr
+	}
+
+	var dir = filepath.Dir(target)
+	if err := AppFs.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var tmp, err = afero.TempFile(AppFs, dir, "."+filepath.Base(target)+".bm-*")
+	if err != nil {
+		// the name of the temporary file means nothing to the user
+		return fmt.Errorf("cannot write %s: %s", target, err.Error())
+	}
+	var tmpName = tmp.Name()
+	// never leave the temporary file around when something goes wrong
+	var fail = func(err error) error {
+		tmp.Close()
+		AppFs.Remove(tmpName)
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fail(err)
+	}
+	if err = AppFs.Chmod(tmpName, mode); err != nil {
+		return fail(err)
+	}
+	if statErr == nil {
+		if uid, gid, ok := fileOwner(info); ok {
+			if err = AppFs.Chown(tmpName, uid, gid); err != nil {
+				return fail(fmt.Errorf("cannot keep the owner of %s: %s", target, err.Error()))
+			}
+		}
+	}
+	if err = AppFs.Rename(tmpName, target); err != nil {
+		return fail(err)
+	}
+
+	// make the rename itself survive a crash, not every filesystem can sync a folder so it is fine if this fails
+	if folder, openErr := AppFs.Open(dir); openErr == nil {
+		folder.Sync()
+		folder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestAtomicWrite(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("writeFileAtomic", func() {
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/config", 0755)
+		})
+
+		g.It("replaces longer content completely", func() {
+			afero.WriteFile(AppFs, "/config/lfrc", []byte("a very long line that should disappear\n"), 0644)
+			g.Assert(writeFileAtomic("/config/lfrc", []byte("short\n"))).IsNil()
+			var text, _ = afero.ReadFile(AppFs, "/config/lfrc")
+			g.Assert(string(text)).Equal("short\n")
+		})
+
+		g.It("keeps the mode of the old file and leaves no temporary file", func() {
+			afero.WriteFile(AppFs, "/config/aliasrc", []byte("old\n"), 0600)
+			g.Assert(writeFileAtomic("/config/aliasrc", []byte("new\n"))).IsNil()
+			var info, _ = AppFs.Stat("/config/aliasrc")
+			g.Assert(info.Mode().Perm()).Equal(os.FileMode(0600))
+			var entries, _ = afero.ReadDir(AppFs, "/config")
+			g.Assert(len(entries)).Equal(1)
+		})
+
+		g.It("creates files that do not exist yet", func() {
+			g.Assert(writeFileAtomic("/config/new", []byte("new\n"))).IsNil()
+			var info, _ = AppFs.Stat("/config/new")
+			g.Assert(info.Mode().Perm()).Equal(os.FileMode(0644))
+		})
+
+		g.It("creates the folder of a new file", func() {
+			g.Assert(writeFileAtomic("/config/shell/aliasrc", []byte("new\n"))).IsNil()
+			var text, _ = afero.ReadFile(AppFs, "/config/shell/aliasrc")
+			g.Assert(string(text)).Equal("new\n")
+		})
+
+		g.It("writes through symlinks instead of replacing them", func() {
+			AppFs = afero.NewOsFs()
+			var dir = t.TempDir()
+			var target = path.Join(dir, "dotfiles-aliasrc")
+			var link = path.Join(dir, "aliasrc")
+			os.WriteFile(target, []byte("old\n"), 0644)
+			os.Symlink(target, link)
+
+			g.Assert(writeFileAtomic(link, []byte("new\n"))).IsNil()
+			var info, _ = os.Lstat(link)
+			g.Assert(info.Mode()&os.ModeSymlink != 0).IsTrue()
+			var text, _ = os.ReadFile(target)
+			g.Assert(string(text)).Equal("new\n")
+		})
+	})
+}
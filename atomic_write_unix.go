@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// the owner of a file, only files from the real filesystem have one
+var fileOwner = func(info fs.FileInfo) (int, int, bool) {
+	var stat, ok = info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package main
+
+import (
+	"io/fs"
+)
+
+// windows files do not have a uid and gid
+var fileOwner = func(info fs.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
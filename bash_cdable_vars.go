@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const variableNameCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+// turns an abbreviation into a shell variable name, every character a variable cannot have becomes _
+var bashVariableName = func(prefix string, abbreviation string) string {
+	var name strings.Builder
+	name.WriteString(prefix)
+	for _, char := range abbreviation {
+		if strings.ContainsRune(variableNameCharacters, char) {
+			name.WriteRune(char)
+		} else {
+			name.WriteRune('_')
+		}
+	}
+	return name.String()
+}
+
+// completes bm_c/<TAB> with the folders under $bm_c, and falls back to the usual completion of cd
+const bashCdableCompletion = `  _bm_cdable_vars() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" name base
+    case "$cur" in
+      %[1]s*/*)
+        name="${cur%%%%/*}"
+        case "$name" in *[!A-Za-z0-9_]*) return 1 ;; esac
+        eval "base=\${$name-}"
+        if [ -n "$base" ]; then
+          local IFS=$'\n'
+          COMPREPLY=($(compgen -d -S / -- "$base/${cur#*/}"))
+          compopt -o nospace 2>/dev/null
+          return 0
+        fi
+        ;;
+      %[1]s*)
+        COMPREPLY=($(compgen -W %[2]s -S / -- "$cur"))
+        compopt -o nospace 2>/dev/null
+        return 0
+        ;;
+    esac
+    if declare -F _cd >/dev/null; then
+      _cd "$@"
+    else
+      COMPREPLY=($(compgen -d -- "$cur"))
+    fi
+  }
+  complete -F _bm_cdable_vars cd
+`
+
+/*
+exports one variable per folder bookmark, so with cdable_vars `cd bm_c` works without an alias
+the rest only makes sense in bash, the alias file may be sourced by other shells too
+*/
+var renderBashCdableVars = func(bms []Bookmark, flags Flags) (string, error) {
+	var prefix = flags.bashVariablePrefix
+	if len(prefix) == 0 || strings.Trim(prefix, variableNameCharacters) != "" || strings.ContainsAny(prefix[:1], "0123456789") {
+		return "", fmt.Errorf("variable prefix %s has to be a valid shell variable name", prefix)
+	}
+
+	var lines = ""
+	var names = []string{}
+	// which abbreviation got the variable first
+	var owners = map[string]string{}
+	for _, bm := range bms {
+		if bm.typ != "dir" {
+			continue
+		}
+		var name = bashVariableName(prefix, bm.abbreviation)
+		if owner, exists := owners[name]; exists {
+			log.Warnf("bookmarks %s and %s both become the variable %s, only %s is exported", owner, bm.abbreviation, name, owner)
+			continue
+		}
+		owners[name] = bm.abbreviation
+		names = append(names, name)
+		var line = fmt.Sprintf("export %s=%s\n", name, shellQuote(resolve(bm.path, flags)))
+		log.Debugln(line)
+		lines += line
+	}
+
+	lines += "if [ -n \"${BASH_VERSION-}\" ]; then\n" +
+		"  shopt -s cdable_vars\n" +
+		fmt.Sprintf(bashCdableCompletion, prefix, singleQuote(strings.Join(names, " "))) +
+		"fi\n"
+	return lines, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+/*
+a setting in a [section] of the config file is the flag called section-key, so [lf] mapping-prefix is --lf-mapping-prefix
+these are the ones that do not follow that rule
+*/
+var configSectionAliases = map[string]map[string]string{
+	"shell": {"alias-file": "alias-file"},
+	"init":  {"cache": "cache"},
+}
+
+// BM_ and the flag name in capitals, so --lf-mapping-prefix is BM_LF_MAPPING_PREFIX
+var configEnvironmentVariable = func(name string) string {
+	return "BM_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// the config file is the flag, then $BM_CONFIG, then the default of the flag
+var configFilePath = func(flagSet *pflag.FlagSet) (string, bool) {
+	var configFlag = flagSet.Lookup("config")
+	if configFlag.Changed {
+		return configFlag.Value.String(), true
+	}
+	if file, exists := os.LookupEnv(configEnvironmentVariable("config")); exists {
+		return file, true
+	}
+	return configFlag.Value.String(), false
+}
+
+// the flags whose default is somewhere in home, so they move with --home-path
+var defaultPaths = map[string]func(xdg xdgDirectories) string{
+	"config":        func(xdg xdgDirectories) string { return xdg.configFile("bookmarker", "config.toml") },
+	"bookmark-file": func(xdg xdgDirectories) string { return xdg.configFile("bookmarker", "list") },
+	"alias-file":    func(xdg xdgDirectories) string { return xdg.configFile("shell", "aliasrc") },
+	"lf-config":     func(xdg xdgDirectories) string { return xdg.configFile("lf", "lfrc") },
+	"lf-marks-file": func(xdg xdgDirectories) string { return xdg.dataFile("lf", "marks") },
+	"fish-file":     func(xdg xdgDirectories) string { return xdg.configFile("fish", "conf.d", "bookmarker.fish") },
+	"zsh-file":      func(xdg xdgDirectories) string { return xdg.configFile("zsh", "bookmarker.zsh") },
+}
+
+// the flags still on their default get the default under homePath, the ones that were set are left alone
+var applyDefaultPaths = func(flagSet *pflag.FlagSet, homePath string) error {
+	var xdg = xdgBaseDirectories(homePath)
+	for name, defaultPath := range defaultPaths {
+		var flag = flagSet.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		// Value.Set does not count as changed, so the source stays the default
+		if err := flag.Value.Set(defaultPath(xdg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the flags of the command and every subcommand, a setting for another command is not a mistake
+var knownFlags = func(root *cobra.Command) map[string]bool {
+	var known = map[string]bool{}
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		var add = func(flag *pflag.Flag) {
+			known[flag.Name] = true
+		}
+		cmd.PersistentFlags().VisitAll(add)
+		cmd.Flags().VisitAll(add)
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(root)
+	return known
+}
+
+// turns the settings of the config file into flag names and values, the values as they would be typed
+var readConfigFile = func(filename string, known map[string]bool) (map[string]string, error) {
+	var text, file, err = readTextFromFile(filename)
+	if file != nil {
+		file.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var settings = map[string]interface{}{}
+	if _, err := toml.Decode(text, &settings); err != nil {
+		return nil, fmt.Errorf("cannot read config file %s: %s", filename, err.Error())
+	}
+
+	var values = map[string]string{}
+	var add = func(name string, value interface{}) error {
+		if name == "config" || !known[name] {
+			return fmt.Errorf("%s: unknown setting %s", filename, name)
+		}
+		switch value := value.(type) {
+		case []interface{}:
+			var items = []string{}
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return fmt.Errorf("%s: %s is not a section", filename, name)
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+		return nil
+	}
+	for key, value := range settings {
+		var section, isSection = value.(map[string]interface{})
+		if !isSection {
+			if err := add(key, value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for sectionKey, sectionValue := range section {
+			var name = key + "-" + sectionKey
+			if alias, exists := configSectionAliases[key][sectionKey]; exists {
+				name = alias
+			}
+			if err := add(name, sectionValue); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+/*
+fills the flags that are not on the command line, first from $BM_..., then from the config file
+what is left keeps its default, the default paths are under the home path from wherever it came from
+sources gets where every value came from, for bm config show
+*/
+var applyConfig = func(cmd *cobra.Command, sources map[string]string) error {
+	var flagSet = cmd.Flags()
+	// -H on the command line moves the config file too
+	if err := applyHomeDefaultPaths(flagSet); err != nil {
+		return err
+	}
+	var filename, explicit = configFilePath(flagSet)
+	var values, err = readConfigFile(filename, knownFlags(cmd.Root()))
+	// a config file is optional, unless it is asked for
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		values = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	var setErr error
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if setErr != nil || flag.Name == "help" {
+			return
+		}
+		var variable = configEnvironmentVariable(flag.Name)
+		var value, fromEnvironment = os.LookupEnv(variable)
+		var fromConfig bool
+		if !fromEnvironment {
+			value, fromConfig = values[flag.Name]
+		}
+		switch {
+		case flag.Changed:
+			sources[flag.Name] = "command line"
+		case fromEnvironment:
+			sources[flag.Name] = "environment " + variable
+			setErr = flagSet.Set(flag.Name, value)
+		case fromConfig:
+			sources[flag.Name] = "config file " + filename
+			setErr = flagSet.Set(flag.Name, value)
+		default:
+			sources[flag.Name] = "default"
+		}
+		if setErr != nil {
+			setErr = fmt.Errorf("invalid value %s for %s (from %s): %s", value, flag.Name, sources[flag.Name], setErr.Error())
+		}
+	})
+	if setErr != nil {
+		return setErr
+	}
+	// the home path can also come from $BM_HOME_PATH or the config file
+	return applyHomeDefaultPaths(flagSet)
+}
+
+var applyHomeDefaultPaths = func(flagSet *pflag.FlagSet) error {
+	var homeFlag = flagSet.Lookup("home-path")
+	if homeFlag == nil {
+		return nil
+	}
+	var homePath, err = getHomePath(Flags{homePath: homeFlag.Value.String()})
+	if err != nil {
+		return err
+	}
+	return applyDefaultPaths(flagSet, homePath)
+}
+
+var newConfigCommand = func(sources map[string]string) *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Settings from the config file and $BM_... variables",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the value of every setting and where it comes from",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var names = []string{}
+			for name := range sources {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, name := range names {
+				var flag = cmd.Flags().Lookup(name)
+				if flag == nil {
+					continue
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", name, flag.Value.String(), sources[name])
+			}
+			writer.Flush()
+		},
+	})
+	return configCmd
+}
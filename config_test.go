@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func TestConfig(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("config file and environment", func() {
+		var flags Flags
+		var newCommand = func() (*cobra.Command, *cobra.Command) {
+			flags = Flags{}
+			var configFile string
+			var root = &cobra.Command{Use: "bm"}
+			root.PersistentFlags().StringVar(&configFile, "config", "/home/me/.config/bookmarker/config.toml", "")
+			root.PersistentFlags().StringVarP(&flags.editor, "editor", "e", "", "")
+			root.PersistentFlags().StringVar(&flags.shellAliasFile, "alias-file", "/home/me/.config/shell/aliasrc", "")
+			root.PersistentFlags().StringVar(&flags.lfMappingPrefix, "lf-mapping-prefix", "g", "")
+			root.PersistentFlags().StringVar(&flags.shellAliasFolderPrefix, "shell-alias-folder-prefix", "cd", "")
+			root.PersistentFlags().StringSliceVar(&flags.generators, "generators", []string{}, "")
+			var initCmd = &cobra.Command{Use: "init"}
+			initCmd.Flags().BoolVar(&flags.initCache, "cache", false, "")
+			root.AddCommand(initCmd)
+			// what cobra does before running a command
+			root.Flags().AddFlagSet(root.PersistentFlags())
+			initCmd.Flags().AddFlagSet(root.PersistentFlags())
+			return root, initCmd
+		}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/config.toml", []byte(
+				"editor = \"nvim\"\n"+
+					"generators = [\"lf\", \"zsh\"]\n"+
+					"[shell]\n"+
+					"alias-file = \"/home/me/.aliases\"\n"+
+					"alias-folder-prefix = \"c\"\n"+
+					"[lf]\n"+
+					"mapping-prefix = \"'\"\n"+
+					"[init]\n"+
+					"cache = true\n",
+			), 0644)
+		})
+
+		g.It("reads sections into flag names", func() {
+			var root, _ = newCommand()
+			var values, err = readConfigFile("/home/me/.config/bookmarker/config.toml", knownFlags(root))
+			g.Assert(err).IsNil()
+			g.Assert(values).Equal(map[string]string{
+				"editor":                    "nvim",
+				"generators":                "lf,zsh",
+				"alias-file":                "/home/me/.aliases",
+				"shell-alias-folder-prefix": "c",
+				"lf-mapping-prefix":         "'",
+				"cache":                     "true",
+			})
+
+			afero.WriteFile(AppFs, "/bad.toml", []byte("[lf]\nnope = 1\n"), 0644)
+			_, err = readConfigFile("/bad.toml", knownFlags(root))
+			g.Assert(err.Error()).Equal("/bad.toml: unknown setting lf-nope")
+		})
+
+		g.It("puts the command line over the environment over the config file", func() {
+			var root, _ = newCommand()
+			t.Setenv("BM_LF_MAPPING_PREFIX", "go")
+			root.Flags().Parse([]string{"-e", "code"})
+			var sources = map[string]string{}
+			g.Assert(applyConfig(root, sources)).IsNil()
+
+			g.Assert(flags.editor).Equal("code")
+			g.Assert(flags.lfMappingPrefix).Equal("go")
+			g.Assert(flags.shellAliasFile).Equal("/home/me/.aliases")
+			g.Assert(flags.generators).Equal([]string{"lf", "zsh"})
+			g.Assert(sources["editor"]).Equal("command line")
+			g.Assert(sources["lf-mapping-prefix"]).Equal("environment BM_LF_MAPPING_PREFIX")
+			g.Assert(sources["alias-file"]).Equal("config file /home/me/.config/bookmarker/config.toml")
+			g.Assert(sources["config"]).Equal("default")
+		})
+
+		g.It("fills the flags of subcommands", func() {
+			var _, initCmd = newCommand()
+			g.Assert(applyConfig(initCmd, map[string]string{})).IsNil()
+			g.Assert(flags.initCache).IsTrue()
+		})
+
+		g.It("moves the default paths with the home path", func() {
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("XDG_DATA_HOME", "")
+			var root, _ = newCommand()
+			var homePath, bookmarkFile, marksFile string
+			root.PersistentFlags().StringVarP(&homePath, "home-path", "H", "/home/me", "")
+			root.PersistentFlags().StringVar(&bookmarkFile, "bookmark-file", "/home/me/.config/bookmarker/list", "")
+			root.PersistentFlags().StringVar(&marksFile, "lf-marks-file", "/home/me/.local/share/lf/marks", "")
+			root.Flags().AddFlagSet(root.PersistentFlags())
+			AppFs.MkdirAll("/other/.config/bookmarker", 0755)
+			afero.WriteFile(AppFs, "/other/.config/bookmarker/config.toml", []byte("editor = \"ed\"\n"), 0644)
+
+			root.Flags().Parse([]string{"-H", "/other", "--lf-marks-file", "/marks"})
+			var sources = map[string]string{}
+			g.Assert(applyConfig(root, sources)).IsNil()
+			g.Assert(bookmarkFile).Equal("/other/.config/bookmarker/list")
+			g.Assert(marksFile).Equal("/marks")
+			g.Assert(flags.editor).Equal("ed")
+			g.Assert(sources["bookmark-file"]).Equal("default")
+		})
+
+		g.It("only needs the config file when it is asked for", func() {
+			AppFs = afero.NewMemMapFs()
+			var root, _ = newCommand()
+			g.Assert(applyConfig(root, map[string]string{})).IsNil()
+			g.Assert(flags.editor).Equal("")
+
+			t.Setenv("BM_CONFIG", "/nowhere.toml")
+			g.Assert(applyConfig(root, map[string]string{}) == nil).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+)
+
+// a line of the bookmark file, kept exactly as it is written
+type documentLine struct {
+	text string
+	// empty for comments, blank lines, variables, includes and lines that cannot be read
+	abbreviation string
+	// byte offsets of the abbreviation in text, without the ! or ? in front of it
+	abbreviationStart int
+	abbreviationEnd   int
+}
+
+/*
+the bookmark file as lines, comments and blank lines included
+turning it back into text gives the same file, byte for byte, so it can be edited without losing anything
+*/
+type bookmarkDocument struct {
+	lines []documentLine
+}
+
+var parseDocumentLine = func(text string) documentLine {
+	var line = documentLine{text: text}
+	var tokens, lexErr = lexLine(text)
+	if lexErr != nil || len(tokens) == 0 || variableDefinition.MatchString(text) || isIncludeLine(text, tokens) {
+		return line
+	}
+	line.abbreviationStart = tokens[0].start
+	line.abbreviationEnd = tokens[0].end
+	line.abbreviation = tokens[0].text
+	if strings.HasPrefix(text[tokens[0].start:], "!") || strings.HasPrefix(text[tokens[0].start:], "?") {
+		line.abbreviationStart++
+		line.abbreviation = line.abbreviation[1:]
+	}
+	return line
+}
+
+var parseDocument = func(text string) bookmarkDocument {
+	var document = bookmarkDocument{}
+	for _, text := range strings.Split(text, "\n") {
+		document.lines = append(document.lines, parseDocumentLine(text))
+	}
+	return document
+}
+
+func (d bookmarkDocument) String() string {
+	var texts = make([]string, 0, len(d.lines))
+	for _, line := range d.lines {
+		texts = append(texts, line.text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// the indexes of the lines that define the abbreviation
+func (d bookmarkDocument) find(abbreviation string) []int {
+	var indexes = []int{}
+	for index, line := range d.lines {
+		if len(line.abbreviation) != 0 && line.abbreviation == abbreviation {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
+// adds a line after the last line that is not blank, and returns its index
+func (d *bookmarkDocument) add(text string) int {
+	var index = len(d.lines)
+	for index > 0 && strings.TrimSpace(d.lines[index-1].text) == "" {
+		index--
+	}
+	d.lines = append(d.lines[:index], append([]documentLine{parseDocumentLine(text)}, d.lines[index:]...)...)
+	// the file should still end with a new line
+	if index == len(d.lines)-1 {
+		d.lines = append(d.lines, documentLine{})
+	}
+	return index
+}
+
+// removes every line that defines the abbreviation, and returns how many there were
+func (d *bookmarkDocument) remove(abbreviation string) int {
+	var kept = []documentLine{}
+	for _, line := range d.lines {
+		if len(line.abbreviation) == 0 || line.abbreviation != abbreviation {
+			kept = append(kept, line)
+		}
+	}
+	var removed = len(d.lines) - len(kept)
+	d.lines = kept
+	return removed
+}
+
+// changes only the abbreviation, the path and the comment after it stay as they are
+func (d *bookmarkDocument) rename(from string, to string) int {
+	var indexes = d.find(from)
+	for _, index := range indexes {
+		var line = d.lines[index]
+		d.lines[index] = parseDocumentLine(line.text[:line.abbreviationStart] + quoteBookmarkToken(to) + line.text[line.abbreviationEnd:])
+	}
+	return len(indexes)
+}
+
+// quotes the text so lexLine reads it back as one token
+var quoteBookmarkToken = func(text string) string {
+	if len(text) != 0 && !strings.ContainsAny(text, " \t\r\n'\"\\$") && !strings.HasPrefix(text, "#") && !strings.HasPrefix(text, "~") {
+		return text
+	}
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
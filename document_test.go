@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestDocument(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("lossless bookmark document", func() {
+		var text = "# my bookmarks\n" +
+			"c .config/   # configs\n" +
+			"\n" +
+			"?w 'My Work/'\n" +
+			"!ls exa -la\n" +
+			"\n"
+
+		g.It("gives back the same text", func() {
+			for _, original := range []string{text, "", "a /tmp/", "a /tmp/\n\n\n", "'unclosed\n"} {
+				g.Assert(parseDocument(original).String()).Equal(original)
+			}
+		})
+
+		g.It("finds abbreviations without the ! or ? in front", func() {
+			var document = parseDocument(text)
+			g.Assert(document.find("c")).Equal([]int{1})
+			g.Assert(document.find("w")).Equal([]int{3})
+			g.Assert(document.find("ls")).Equal([]int{4})
+			g.Assert(document.find("#")).Equal([]int{})
+			g.Assert(parseDocument("$svc = /srv\n").lines[0].abbreviation).Equal("")
+		})
+
+		g.It("adds after the last bookmark", func() {
+			var document = parseDocument(text)
+			g.Assert(document.add("t /tmp/")).Equal(5)
+			g.Assert(document.String()).Equal(text[:len(text)-1] + "t /tmp/\n\n")
+
+			document = parseDocument("c .config/")
+			document.add("t /tmp/")
+			g.Assert(document.String()).Equal("c .config/\nt /tmp/\n")
+
+			document = parseDocument("")
+			document.add("t /tmp/")
+			g.Assert(document.String()).Equal("t /tmp/\n")
+		})
+
+		g.It("removes and renames without touching the rest", func() {
+			var document = parseDocument(text)
+			g.Assert(document.remove("ls")).Equal(1)
+			g.Assert(document.remove("ls")).Equal(0)
+			g.Assert(document.String()).Equal("# my bookmarks\nc .config/   # configs\n\n?w 'My Work/'\n\n")
+
+			g.Assert(document.rename("c", "my conf")).Equal(1)
+			g.Assert(document.rename("w", "work")).Equal(1)
+			g.Assert(document.String()).Equal("# my bookmarks\n'my conf' .config/   # configs\n\n?work 'My Work/'\n\n")
+			g.Assert(document.find("my conf")).Equal([]int{1})
+		})
+
+		g.It("quotes tokens so they are read back the same", func() {
+			for _, token := range []string{"plain", "with space", "it's", "#hash", `back\slash`, "$HOME", "~me", ""} {
+				var tokens, lexErr = lexLineExpanding(quoteBookmarkToken(token), func(string) (string, bool) { return "", false })
+				g.Assert(lexErr == nil).IsTrue()
+				if len(token) != 0 {
+					g.Assert(tokens[0].text).Equal(token)
+				}
+			}
+		})
+	})
+
+	g.Describe("adding bookmarks", func() {
+		var flags = Flags{homePath: "/home/me", bookmarkFile: "list", missingPolicy: "error"}
+
+		g.It("writes paths inside home relative to it", func() {
+			g.Assert(bookmarkPath("/home/me/work", true, flags)).Equal("work/")
+			g.Assert(bookmarkPath("/home/me/notes.txt", false, flags)).Equal("notes.txt")
+			g.Assert(bookmarkPath("/home/meme", true, flags)).Equal("/home/meme/")
+			g.Assert(bookmarkPath("/home/me", true, flags)).Equal("/home/me/")
+		})
+
+		g.It("checks the new line like the rest of the file", func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/work", 0755)
+			var document = parseDocument("# comment\n")
+			var index = document.add("w work/")
+			g.Assert(checkDocumentLine(document, index, flags)).IsNil()
+
+			index = document.add("x gone/")
+			var err = checkDocumentLine(document, index, flags)
+			g.Assert(err == nil).IsFalse()
+			g.Assert(err.(ParseErrors)[0].Line).Equal(3)
+		})
+
+		g.It("does not blame the new line for a line of an included file", func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/work", 0755)
+			afero.WriteFile(AppFs, "/team", []byte("# team\nx gone/\n"), 0644)
+			var listFlags = flags
+			listFlags.bookmarkFile = "/list"
+			var document = parseDocument("include /team\n")
+			var index = document.add("w work/")
+			g.Assert(index).Equal(1)
+			g.Assert(checkDocumentLine(document, index, listFlags)).IsNil()
+		})
+
+		g.It("does not save a document with errors", func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/work", 0755)
+			afero.WriteFile(AppFs, "/list", []byte("x gone/\n"), 0644)
+			var listFlags = flags
+			listFlags.bookmarkFile = "/list"
+			listFlags.generators = []string{"shell"}
+			listFlags.shellAliasFile = "/aliasrc"
+			listFlags.editor = "vim"
+			var document = parseDocument("x gone/\n")
+			document.add("w work/")
+			var err = saveDocument(document, listFlags)
+			g.Assert(err.(ParseErrors)[0].Line).Equal(1)
+			var text, _ = afero.ReadFile(AppFs, "/list")
+			g.Assert(string(text)).Equal("x gone/\n")
+
+			document.remove("x")
+			g.Assert(saveDocument(document, listFlags)).IsNil()
+			text, _ = afero.ReadFile(AppFs, "/aliasrc")
+			g.Assert(len(text) != 0).IsTrue()
+		})
+	})
+}
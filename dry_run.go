@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+/*
+runs fn with AppFs pointing at an in-memory layer on top of the real filesystem
+nothing is written to the disk, every file fn would have written is printed as a unified diff instead
+the returned bool tells if anything would change
+*/
+var dryRun = func(fn func() error, output io.Writer) (bool, error) {
+	var base = AppFs
+	var layer = afero.NewMemMapFs()
+	AppFs = afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(base), layer)
+	defer func() { AppFs = base }()
+
+	if err := fn(); err != nil {
+		return false, err
+	}
+
+	// every file in the layer has been written by fn
+	var written = []string{}
+	var walkErr = afero.Walk(layer, "/", func(filename string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			written = append(written, filename)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return false, walkErr
+	}
+	sort.Strings(written)
+
+	// NO_COLOR is the usual way to ask for no colors, see no-color.org
+	// a pipe or a file gets no colors either, the escapes would end up as text in there
+	var _, noColor = os.LookupEnv("NO_COLOR")
+	noColor = noColor || !isTerminal(output)
+	var changed = false
+	for _, filename := range written {
+		var oldName = filename
+		var oldText, readErr = afero.ReadFile(base, filename)
+		if errors.Is(readErr, fs.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if readErr != nil {
+			return false, readErr
+		}
+		var newText, newErr = afero.ReadFile(layer, filename)
+		if newErr != nil {
+			return false, newErr
+		}
+
+		if bytes.Equal(oldText, newText) {
+			continue
+		}
+		changed = true
+		io.WriteString(output, unifiedDiff(oldName, filename, string(oldText), string(newText), !noColor))
+	}
+	return changed, nil
+}
+
+// a terminal is a character device, pipes and files are not
+var isTerminal = func(output io.Writer) bool {
+	var file, isFile = output.(*os.File)
+	if !isFile {
+		return false
+	}
+	var info, err = file.Stat()
+	return err == nil && info.Mode()&fs.ModeCharDevice != 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestDryRun(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("unifiedDiff", func() {
+		g.It("returns nothing for the same text", func() {
+			g.Assert(unifiedDiff("a", "b", "x\ny\n", "x\ny\n", false)).Equal("")
+		})
+
+		g.It("prints hunks with context", func() {
+			var diff = unifiedDiff("old", "new", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\nfive\n6\n7\n8\n9\n", false)
+			g.Assert(diff).Equal("--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n")
+		})
+
+		g.It("shows a missing newline at the end", func() {
+			var diff = unifiedDiff("old", "new", "a\nb", "a\nb\n", false)
+			g.Assert(diff).Equal("--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n")
+		})
+
+		g.It("handles new files", func() {
+			var diff = unifiedDiff("/dev/null", "new", "", "a\nb\n", false)
+			g.Assert(diff).Equal("--- /dev/null\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n")
+		})
+	})
+
+	g.Describe("dryRun", func() {
+		g.BeforeEach(func() {
+			os.Setenv("NO_COLOR", "1")
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/shell", 0755)
+			afero.WriteFile(AppFs, "/home/shell/aliasrc", []byte("alias ll='ls -l'\n"), 0644)
+		})
+
+		g.After(func() {
+			os.Unsetenv("NO_COLOR")
+		})
+
+		g.It("counts a newline at the end as a change", func() {
+			afero.WriteFile(AppFs, "/home/marks", []byte("a:/srv"), 0644)
+			var output bytes.Buffer
+			var changed, err = dryRun(func() error {
+				return writeFileAtomic("/home/marks", []byte("a:/srv\n"))
+			}, &output)
+			g.Assert(err).IsNil()
+			g.Assert(changed).IsTrue()
+			g.Assert(strings.Contains(output.String(), "\\ No newline at end of file")).IsTrue()
+		})
+
+		g.It("leaves the colors out when the output is not a terminal", func() {
+			os.Unsetenv("NO_COLOR")
+			defer os.Setenv("NO_COLOR", "1")
+			var output bytes.Buffer
+			dryRun(func() error {
+				return updateManagedBlock("/home/shell/aliasrc", "alias cdc='cd /home/.config'\n", Flags{})
+			}, &output)
+			g.Assert(strings.Contains(output.String(), "\x1b[")).IsFalse()
+		})
+
+		g.It("prints the diff without touching the files", func() {
+			var output bytes.Buffer
+			var changed, err = dryRun(func() error {
+				return updateManagedBlock("/home/shell/aliasrc", "alias cdc='cd /home/.config'\n", Flags{})
+			}, &output)
+			g.Assert(err).IsNil()
+			g.Assert(changed).IsTrue()
+			g.Assert(output.String()).Equal("--- /home/shell/aliasrc\n+++ /home/shell/aliasrc\n@@ -1 +1,6 @@\n alias ll='ls -l'\n+" +
+				START_GENERATION_STRING + "\n+\n+alias cdc='cd /home/.config'\n+\n+" + END_GENERATION_STRING + "\n")
+
+			var text, _ = afero.ReadFile(AppFs, "/home/shell/aliasrc")
+			g.Assert(string(text)).Equal("alias ll='ls -l'\n")
+		})
+
+		g.It("says nothing changes when the files are up to date", func() {
+			var output bytes.Buffer
+			var changed, err = dryRun(func() error {
+				return writeFileAtomic("/home/shell/aliasrc", []byte("alias ll='ls -l'\n"))
+			}, &output)
+			g.Assert(err).IsNil()
+			g.Assert(changed).IsFalse()
+			g.Assert(output.Len()).Equal(0)
+		})
+	})
+}
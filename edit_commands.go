@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// how a path is written in the bookmark file: relative to home when it is inside home, with a trailing / for folders
+var bookmarkPath = func(target string, isDir bool, flags Flags) string {
+	var written = filepath.Clean(target)
+	if relative, err := filepath.Rel(flags.homePath, written); err == nil && relative != "." && !strings.HasPrefix(relative, "..") {
+		written = relative
+	}
+	if isDir && !strings.HasSuffix(written, "/") {
+		written += "/"
+	}
+	return written
+}
+
+var readDocument = func(flags Flags) (bookmarkDocument, error) {
+	var text, file, err = readTextFromFile(flags.bookmarkFile)
+	if err != nil {
+		return bookmarkDocument{}, err
+	}
+	file.Close()
+	return parseDocument(text), nil
+}
+
+// checks a new line with the same rules as parseFile, with the variables defined above it
+var checkDocumentLine = func(document bookmarkDocument, index int, flags Flags) error {
+	var _, errs = parseFile(document.String(), flags)
+	var lineErrs = ParseErrors{}
+	for _, err := range errs {
+		// the same line number in an included file is another line
+		if err.Line == index+1 && err.File == flags.bookmarkFile && len(err.IncludedFrom) == 0 {
+			lineErrs = append(lineErrs, err)
+		}
+	}
+	if lineErrs.HasErrors() {
+		return lineErrs
+	}
+	return nil
+}
+
+/*
+saves the document and generates everything again, so the outputs never fall behind the bookmark file
+the whole document is checked first, nothing is written when any line has an error
+with --dry-run nothing is written, the diff of the bookmark file and the outputs is printed instead
+*/
+var saveDocument = func(document bookmarkDocument, flags Flags) error {
+	var text = document.String()
+	if _, errs, _ := parseBookmarkFiles(text, flags); errs.HasErrors() {
+		return errs
+	}
+	var save = func() error {
+		if err := writeFileAtomic(flags.bookmarkFile, []byte(text)); err != nil {
+			return err
+		}
+		return generateAll(flags)
+	}
+	if flags.dryRun {
+		var changed, err = dryRun(save, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if changed {
+			os.Exit(1)
+		}
+		return nil
+	}
+	return save()
+}
+
+var newEditCommands = func(flags *Flags) []*cobra.Command {
+	var addCmd = &cobra.Command{
+		Use:   "add <abbreviation> [path]",
+		Short: "Add a bookmark to the bookmark file, the path is the current folder if it is not given",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var abbreviation = args[0]
+			exitIf(checkAliasName(abbreviation))
+
+			var target string
+			if len(args) == 2 {
+				target = args[1]
+			} else {
+				var cwd, err = os.Getwd()
+				exitIf(err)
+				target = cwd
+			}
+			if !filepath.IsAbs(target) {
+				var cwd, err = os.Getwd()
+				exitIf(err)
+				target = filepath.Join(cwd, target)
+			}
+			var info, statErr = AppFs.Stat(target)
+			exitIf(statErr)
+
+			var document, err = readDocument(*flags)
+			exitIf(err)
+			if len(document.find(abbreviation)) != 0 {
+				exitIf(fmt.Errorf("there is already a bookmark called %s, use bm mv or bm rm first", abbreviation))
+			}
+			var index = document.add(abbreviation + " " + quoteBookmarkToken(bookmarkPath(target, info.IsDir(), *flags)))
+			exitIf(checkDocumentLine(document, index, *flags))
+			exitIf(saveDocument(document, *flags))
+		},
+	}
+
+	var rmCmd = &cobra.Command{
+		Use:   "rm <abbreviation>",
+		Short: "Remove a bookmark from the bookmark file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var document, err = readDocument(*flags)
+			exitIf(err)
+			if document.remove(args[0]) == 0 {
+				exitIf(fmt.Errorf("there is no bookmark called %s", args[0]))
+			}
+			exitIf(saveDocument(document, *flags))
+		},
+	}
+
+	var mvCmd = &cobra.Command{
+		Use:   "mv <old abbreviation> <new abbreviation>",
+		Short: "Rename a bookmark in the bookmark file",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			exitIf(checkAliasName(args[1]))
+			var document, err = readDocument(*flags)
+			exitIf(err)
+			if len(document.find(args[1])) != 0 {
+				exitIf(fmt.Errorf("there is already a bookmark called %s", args[1]))
+			}
+			if document.rename(args[0], args[1]) == 0 {
+				exitIf(fmt.Errorf("there is no bookmark called %s", args[0]))
+			}
+			exitIf(saveDocument(document, *flags))
+		},
+	}
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the bookmarks in the bookmark file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// a listing should show every bookmark, even the ones missing on this machine
+			if !cmd.Flags().Changed("missing") {
+				flags.missingPolicy = "warn"
+			}
+			var bms, err = readBookmarks(*flags)
+			exitIf(err)
+			if len(bms) == 0 {
+				exitIf(errors.New("there are no bookmarks in " + flags.bookmarkFile))
+			}
+			var writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, bm := range bms {
+				var target = bm.path
+				if bm.typ != "shell" {
+					target = resolve(bm.path, *flags)
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", bm.abbreviation, bm.typ, target)
+			}
+			writer.Flush()
+		},
+	}
+
+	return []*cobra.Command{addCmd, rmCmd, mvCmd, listCmd}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestFish(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("generate correct fish bookmarks", func() {
+		var homeDir, _ = os.UserHomeDir()
+		var flags = Flags{
+			homePath:               homeDir,
+			fishFile:               path.Join(homeDir, ".config", "fish", "conf.d", "bookmarker.fish"),
+			shellAliasFolderPrefix: "c",
+			shellAliasFilePrefix:   "cf",
+		}
+		var readFishFile = func() string {
+			var text, _, _ = readTextFromFile(flags.fishFile)
+			return text
+		}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+		})
+
+		g.It("with dir type", func() {
+			var bookmarks = []Bookmark{
+				{
+					typ:          "dir",
+					path:         "weird/folder",
+					abbreviation: "w",
+				},
+				{
+					typ:          "dir",
+					path:         "/it's here",
+					abbreviation: "q",
+				},
+			}
+			var want = fishCompletionHelper +
+				fmt.Sprintf("function cw --description 'bookmark %[1]s/weird/folder'\n    builtin cd %[1]s/weird/folder/\"$argv[1]\"\nend\n", homeDir) +
+				fmt.Sprintf("complete --command cw --no-files --arguments '(__bm_complete_subdirs %s/weird/folder)'\n", homeDir) +
+				"function cq --description 'bookmark /it\\'s here'\n    builtin cd '/it\\'s here'/\"$argv[1]\"\nend\n" +
+				"complete --command cq --no-files --arguments '(__bm_complete_subdirs \\'/it\\\\\\'s here\\')'\n"
+			g.Assert(generateFish(bookmarks, flags)).IsNil()
+			g.Assert(readFishFile()).Equal(inManagedBlock(want))
+		})
+
+		g.It("with file type", func() {
+			var bookmarks = []Bookmark{
+				{
+					typ:          "file",
+					path:         "/etc/hosts",
+					abbreviation: "h",
+				},
+			}
+			var want = fishCompletionHelper +
+				"function cfh --description 'bookmark /etc/hosts'\n    eval $EDITOR (string escape -- /etc/hosts $argv)\nend\n"
+			g.Assert(generateFish(bookmarks, flags)).IsNil()
+			g.Assert(readFishFile()).Equal(inManagedBlock(want))
+
+			var editorFlags = flags
+			editorFlags.editor = "code --wait"
+			want = fishCompletionHelper +
+				"function cfh --description 'bookmark /etc/hosts'\n    code --wait /etc/hosts $argv\nend\n"
+			g.Assert(generateFish(bookmarks, editorFlags)).IsNil()
+			g.Assert(readFishFile()).Equal(inManagedBlock(want))
+		})
+
+		g.It("with alias shell", func() {
+			var bookmarks = []Bookmark{
+				{
+					typ:          "shell",
+					path:         "sudo apt update && sudo apt upgrade",
+					abbreviation: "update",
+				},
+				{
+					typ:          "shell",
+					path:         "exa -la",
+					abbreviation: "ls",
+				},
+			}
+			var want = fishCompletionHelper +
+				"abbr --add --global -- update 'sudo apt update && sudo apt upgrade'\n" +
+				"abbr --add --global -- ls 'exa -la'\n"
+			g.Assert(generateFish(bookmarks, flags)).IsNil()
+			g.Assert(readFishFile()).Equal(inManagedBlock(want))
+		})
+
+		g.It("rejects abbreviations that are not valid names", func() {
+			var err = generateFish([]Bookmark{{typ: "shell", path: "ls", abbreviation: "a;b"}}, flags)
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerGenerator(generatorFunc{
+		name:        "fish",
+		description: "fish functions for folders and files, abbreviations for commands",
+		generate:    generateFish,
+		optIn:       true,
+	})
+}
+
+// characters that mean nothing special to fish
+const fishSafeCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_+=:,./-@"
+
+// fish only knows two escapes inside single quotes: \' and \\
+var fishQuote = func(word string) string {
+	if len(word) == 0 {
+		return "''"
+	}
+	for _, char := range word {
+		if !strings.ContainsRune(fishSafeCharacters, char) {
+			return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(word) + "'"
+		}
+	}
+	return word
+}
+
+// lists the folders under the bookmark that match what has been typed so far
+const fishCompletionHelper = `function __bm_complete_subdirs --description 'folders under a bookmark'
+    set -l base $argv[1]
+    set -l token (commandline -ct)
+    for dir in $base/$token*/
+        string replace -- $base/ '' $dir
+    end
+end
+`
+
+/*
+folder bookmarks become functions that cd into the folder, or a folder under it if one is given
+file bookmarks become functions that open the file in the editor
+shell bookmarks become abbreviations, so the command is expanded on the command line before it runs
+*/
+var renderFish = func(bms []Bookmark, flags Flags) (string, error) {
+	// fish can start $EDITOR by itself, so the editor only has to be known now when it is given as a flag
+	var editorCommand = "eval $EDITOR (string escape -- %s $argv)"
+	if len(flags.editor) != 0 {
+		var tokens, lexErr = lexLine(flags.editor)
+		if lexErr != nil {
+			return "", fmt.Errorf("cannot read editor command %s: %s", flags.editor, lexErr.Error())
+		}
+		var words = []string{}
+		for _, tok := range tokens {
+			words = append(words, fishQuote(tok.text))
+		}
+		editorCommand = strings.Join(words, " ") + " %s $argv"
+	}
+
+	var lines = fishCompletionHelper
+	for _, bm := range bms {
+		var name, code string
+		if bm.typ == "dir" {
+			name = flags.shellAliasFolderPrefix + bm.abbreviation
+			var dir = fishQuote(resolve(bm.path, flags))
+			code = fmt.Sprintf("function %s --description %s\n    builtin cd %s/\"$argv[1]\"\nend\n", name, fishQuote("bookmark "+resolve(bm.path, flags)), dir)
+			code += fmt.Sprintf("complete --command %s --no-files --arguments %s\n", name, fishQuote("(__bm_complete_subdirs "+dir+")"))
+		} else if bm.typ == "file" {
+			name = flags.shellAliasFilePrefix + bm.abbreviation
+			var file = fishQuote(resolve(bm.path, flags))
+			code = fmt.Sprintf("function %s --description %s\n    %s\nend\n", name, fishQuote("bookmark "+resolve(bm.path, flags)), fmt.Sprintf(editorCommand, file))
+		} else {
+			name = bm.abbreviation
+			code = fmt.Sprintf("abbr --add --global -- %s %s\n", name, fishQuote(bm.path))
+		}
+		if err := checkAliasName(name); err != nil {
+			return "", fmt.Errorf("cannot create a fish bookmark for %s: %s", bm.abbreviation, err.Error())
+		}
+		log.Debugln(code)
+		lines += code
+	}
+	return lines, nil
+}
+
+// conf.d is read by every fish on start up, so nothing has to be sourced by hand
+var generateFish = func(bms []Bookmark, flags Flags) error {
+	var lines, err = renderFish(bms, flags)
+	if err != nil {
+		return err
+	}
+	return updateManagedBlock(flags.fishFile, lines, flags)
+}
@@ -1,79 +1,131 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"os"
-	"path"
+	"io/fs"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
-var generateLfMappings = func(bms []Bookmark, flags Flags) error {
-	const END_GENERATION_STRING = "### End of BOOKMARKER generation"
-	const START_GENERATION_STRING = "### Automatically generated by BOOKMARKER ###"
-	var lfConfig, err = AppFs.OpenFile(path.Join(flags.homePath, ".config", "lf", "lfrc"), os.O_RDWR, os.ModeAppend)
-	if err != nil {
-		return err
-	}
-	defer lfConfig.Close()
+func init() {
+	registerGenerator(generatorFunc{
+		name:        "lf",
+		description: "lf mappings for folders and files, written to a section of lfrc",
+		generate:    generateLfMappings,
+	})
+}
 
-	// generate the required strings first
-	var lines = START_GENERATION_STRING + "\n\n"
-	for _, bm := range bms {
-		// we don't need to carry about paths which are not directories
-		if bm.typ == "dir" {
-			var line = fmt.Sprintf("map %s%s cd %s\n", flags.lfMappingPrefix, bm.abbreviation, resolve(bm.path, flags))
-			log.Debugln(line)
-			lines += line
+const lfSafeCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_+=:,./-@"
+
+// lf reads '...' as it is and knows \\ and \" inside "..."
+var lfQuote = func(word string) string {
+	if len(word) == 0 {
+		return "''"
+	}
+	for _, char := range word {
+		if !strings.ContainsRune(lfSafeCharacters, char) {
+			if !strings.Contains(word, "'") {
+				return "'" + word + "'"
+			}
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
 		}
 	}
-	lines += "\n" + END_GENERATION_STRING + "\n"
+	return word
+}
 
-	// remove the old section with new section
-	var scanner = bufio.NewScanner(lfConfig)
-	var success bool
+// the key sequences the lf generator maps for the bookmark
+var lfBookmarkKeys = func(bm Bookmark, flags Flags) []string {
+	var keys = []string{}
+	switch bm.typ {
+	case "dir":
+		keys = append(keys, flags.lfMappingPrefix+bm.abbreviation)
+	case "file":
+		for _, prefix := range []string{flags.lfFileSelectPrefix, flags.lfFileOpenPrefix} {
+			if len(prefix) != 0 {
+				keys = append(keys, prefix+bm.abbreviation)
+			}
+		}
+	}
+	return keys
+}
 
-	var keptLines = ""
-	var insideGenerationSection = false
+/*
+folders get a mapping that goes there
+files get a mapping that selects them, which also goes to the folder they are in,
+and one that opens them in the editor, each with its own prefix, an empty prefix leaves it out
+*/
+var renderLfMappings = func(bms []Bookmark, flags Flags) (string, error) {
+	var editor = "$EDITOR"
+	if len(flags.editor) != 0 {
+		var quoted, err = quoteEditorCommand(flags.editor)
+		if err != nil {
+			return "", err
+		}
+		editor = quoted
+	}
 
-	// finds the lines which are enclosed by the two comments
-	// and not include them
-	for {
-		success = scanner.Scan()
-		if !success {
-			err = scanner.Err()
-			if err != nil {
-				return err
+	var lines = ""
+	for _, bm := range bms {
+		var target = resolve(bm.path, flags)
+		var mappings = []string{}
+		switch bm.typ {
+		case "dir":
+			mappings = append(mappings, fmt.Sprintf("map %s%s cd %s\n", flags.lfMappingPrefix, bm.abbreviation, lfQuote(target)))
+		case "file":
+			if len(flags.lfFileSelectPrefix) != 0 {
+				mappings = append(mappings, fmt.Sprintf("map %s%s select %s\n", flags.lfFileSelectPrefix, bm.abbreviation, lfQuote(target)))
+			}
+			// lf gives the rest of the line to the shell as it is
+			if len(flags.lfFileOpenPrefix) != 0 {
+				mappings = append(mappings, fmt.Sprintf("map %s%s $%s %s\n", flags.lfFileOpenPrefix, bm.abbreviation, editor, shellQuote(target)))
 			}
-			break
 		}
-		var line = scanner.Text()
+		for _, line := range mappings {
+			log.Debugln(line)
+			lines += line
+		}
+	}
+	return lines, nil
+}
 
-		// omit the lines and check if we are out of it
-		if insideGenerationSection {
-			if line == END_GENERATION_STRING {
-				insideGenerationSection = false
-			}
-			continue
+/*
+lfrc and its folder are created when they do not exist, lf reads it the next time it starts
+without lf on $PATH there is a warning, so people who do not use lf know how to leave it out
+*/
+var generateLfMappings = func(bms []Bookmark, flags Flags) error {
+	var lfrc, file, err = readTextFromFile(flags.lfConfigFile)
+	if file != nil {
+		file.Close()
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, found := findExecutable("lf"); !found {
+			log.Warnf("lf is not installed, %s is created anyway, use --skip lf to leave lf out", flags.lfConfigFile)
 		}
+	} else if err != nil {
+		return err
+	}
 
-		// trigger inside generation once the ### line is reached
-		if line == START_GENERATION_STRING {
-			insideGenerationSection = true
+	// a bookmark that takes the keys of another mapping makes one of them useless
+	var problems = []string{}
+	for _, conflict := range findLfConflicts(bms, lfrc, flags) {
+		if conflict.severity == SeverityError {
+			problems = append(problems, conflict.message)
 		} else {
-			keptLines += line + "\n"
+			log.Warnln(conflict.message)
 		}
 	}
+	if len(problems) != 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
 
-	var allLines = keptLines + lines
-
-	// go back to the beginning and overwrite everything
-	lfConfig.Seek(0, 0)
-
-	var _, writeErr = lfConfig.WriteString(allLines)
-	if writeErr != nil {
-		return writeErr
+	// generate the required strings first
+	lines, err := renderLfMappings(bms, flags)
+	if err != nil {
+		return err
 	}
-	return nil
+
+	// remove the old section with new section
+	return updateManagedBlock(flags.lfConfigFile, lines, flags)
 }
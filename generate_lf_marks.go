@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerGenerator(generatorFunc{
+		name:        "lf-marks",
+		description: "lf marks for folders with a single character abbreviation, merged into the marks file of lf",
+		generate:    generateLfMarks,
+		optIn:       true,
+	})
+}
+
+// reads key:path lines, the format lf uses for its marks file
+var parseLfMarks = func(text string) map[string]string {
+	var marks = map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		var parts = strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && len(parts[0]) != 0 {
+			marks[parts[0]] = parts[1]
+		}
+	}
+	return marks
+}
+
+// sorted by key, like lf writes it
+var renderLfMarks = func(marks map[string]string) string {
+	var keys = make([]string, 0, len(marks))
+	for key := range marks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines = ""
+	for _, key := range keys {
+		lines += fmt.Sprintf("%s:%s\n", key, marks[key])
+	}
+	return lines
+}
+
+// the marks file cannot have comments, so the marks we wrote last time are remembered in a file of our own
+var lfMarksStateFile = func(flags Flags) string {
+	var name = "lf-marks"
+	if len(flags.profile) != 0 {
+		name += "-" + flags.profile
+	}
+	return xdgBaseDirectories(flags.homePath).stateFile("bookmarker", name)
+}
+
+var readLfMarksFile = func(filename string) (map[string]string, error) {
+	var text, file, err = readTextFromFile(filename)
+	if file != nil {
+		file.Close()
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return parseLfMarks(text), nil
+}
+
+/*
+lf only has single keys for marks, so only folders with a single character abbreviation become marks
+the marks set in lf stay, except the ones we wrote last time that are no longer bookmarks
+a bookmark wins over a mark set in lf with the same key, with a warning
+*/
+var generateLfMarks = func(bms []Bookmark, flags Flags) error {
+	var marks, err = readLfMarksFile(flags.lfMarksFile)
+	if err != nil {
+		return err
+	}
+	var stateFile = lfMarksStateFile(flags)
+	previous, err := readLfMarksFile(stateFile)
+	if err != nil {
+		return err
+	}
+	for key, target := range previous {
+		// a mark changed in lf since then belongs to the user now
+		if marks[key] == target {
+			delete(marks, key)
+		}
+	}
+
+	var written = map[string]string{}
+	for _, bm := range bms {
+		if bm.typ != "dir" {
+			continue
+		}
+		if utf8.RuneCountInString(bm.abbreviation) != 1 {
+			log.Warnf("bookmark %s is too long to be an lf mark, lf marks are a single character", bm.abbreviation)
+			continue
+		}
+		var target = resolve(bm.path, flags)
+		if existing, exists := marks[bm.abbreviation]; exists && existing != target {
+			log.Warnf("bookmark %s replaces the lf mark %s:%s", bm.abbreviation, bm.abbreviation, existing)
+		}
+		marks[bm.abbreviation] = target
+		written[bm.abbreviation] = target
+	}
+
+	if err := writeFileAtomic(flags.lfMarksFile, []byte(renderLfMarks(marks))); err != nil {
+		return err
+	}
+	return writeFileAtomic(stateFile, []byte(renderLfMarks(written)))
+}
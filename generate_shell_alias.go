@@ -4,41 +4,140 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+
 	log "github.com/sirupsen/logrus"
 )
+
+func init() {
+	registerGenerator(generatorFunc{
+		name:        "shell",
+		description: "shell aliases for folders, files and commands, written to the alias file",
+		generate:    generateShellAliases,
+	})
+}
+
+// the aliases only replace their own section of the alias file
+// so hand-written aliases in the same file survive
 var generateShellAliases = func(bms []Bookmark, flags Flags) error {
+	var lines, err = renderShellAliases(bms, flags)
+	if err != nil {
+		return err
+	}
+	return updateManagedBlock(flags.shellAliasFile, lines, flags)
+}
+
+// starts $EDITOR when a file bookmark is used, the eval lets $EDITOR have arguments (code --wait)
+const shellRuntimeEditor = `_bm_editor() { eval "${EDITOR:-vi}" '"$@"'; }
+`
+
+// every path and the editor are quoted, then the whole alias value is quoted again
+// because the shell reads the value one more time when the alias is used
+// with --shell-functions folders and files become functions instead, which take a subpath or more editor arguments
+var renderShellAliases = func(bms []Bookmark, flags Flags) (string, error) {
 	var editor string
+	var lines = ""
+	var editorCommand string
 	if len(flags.editor) == 0 {
 
 		var _editor, exists = os.LookupEnv("EDITOR")
-		if !exists {
-			return errors.New("$EDITOR variable does not exist")
+		if !exists && !flags.editorAtRuntime {
+			return "", errors.New("$EDITOR variable does not exist")
 		}
 		editor = _editor
 	} else {
 		editor = flags.editor
 	}
-	var aliasFile, err = AppFs.Create(flags.shellAliasFile)
-	if err != nil {
-		return err
+	if len(editor) == 0 && flags.editorAtRuntime {
+		editorCommand = "_bm_editor"
+		lines = shellRuntimeEditor
+	} else {
+		var editorErr error
+		if editorCommand, editorErr = quoteEditorCommand(editor); editorErr != nil {
+			return "", editorErr
+		}
 	}
-	defer aliasFile.Close()
-	var lines = ""
+	// the folder functions and where they go, for the completion
+	var folderFunctions = []string{}
+	var folders = []string{}
 	for _, bm := range bms {
-		var line string
+		var name, command string
 		if bm.typ == "dir" {
-			line = fmt.Sprintf("alias %s%s='cd %s'\n", flags.shellAliasFolderPrefix, bm.abbreviation, resolve(bm.path, flags))
+			name = flags.shellAliasFolderPrefix + bm.abbreviation
+			command = "cd " + shellQuote(resolve(bm.path, flags))
 		} else if bm.typ == "file" {
-			line = fmt.Sprintf("alias %s%s='%s %s'\n", flags.shellAliasFilePrefix, bm.abbreviation, editor, resolve(bm.path, flags))
+			name = flags.shellAliasFilePrefix + bm.abbreviation
+			command = editorCommand + " " + shellQuote(resolve(bm.path, flags))
 		} else {
-			line = fmt.Sprintf("alias %s='%s'\n", bm.abbreviation, bm.path)
+			// this one is already shell code, it only needs to survive the quoting
+			name = bm.abbreviation
+			command = bm.path
+		}
+		if err := checkAliasName(name); err != nil {
+			return "", fmt.Errorf("cannot create a shell alias for %s: %s", bm.abbreviation, err.Error())
+		}
+		var line = fmt.Sprintf("alias %s=%s\n", name, singleQuote(command))
+		// functions can take arguments, aliases cannot
+		// a function replaces the builtin with its name for good, so those names are not allowed
+		if flags.shellFunctions && bm.typ != "shell" && contains(shellBuiltins, name) {
+			return "", fmt.Errorf("cannot create a shell function for %s: %s is a shell builtin", bm.abbreviation, name)
+		}
+		if flags.shellFunctions && bm.typ == "dir" {
+			// builtin, so a cd function of the user (or this one) is not called instead
+			line = fmt.Sprintf("%s() { builtin cd %s/\"${1-}\"; }\n", name, shellQuote(resolve(bm.path, flags)))
+			folderFunctions = append(folderFunctions, name)
+			folders = append(folders, resolve(bm.path, flags))
+		} else if flags.shellFunctions && bm.typ == "file" {
+			line = fmt.Sprintf("%s() { %s \"$@\"; }\n", name, command)
 		}
 		log.Debugln(line)
 		lines += line
 	}
-	var _, writeErr = aliasFile.WriteString(lines)
-	if writeErr != nil {
-		return writeErr
+	if len(folderFunctions) != 0 {
+		lines += renderSubpathCompletion(folderFunctions, folders)
+	}
+	if flags.bashCdableVars {
+		var vars, err = renderBashCdableVars(bms, flags)
+		if err != nil {
+			return "", err
+		}
+		lines += vars
+	}
+	return lines, nil
+}
+
+// completes the argument of a folder function with the folders under the bookmark, in bash and zsh
+var renderSubpathCompletion = func(names []string, folders []string) string {
+	var cases = ""
+	var quotedNames = make([]string, 0, len(names))
+	for index, name := range names {
+		cases += fmt.Sprintf("    %s) printf '%%s\\n' %s ;;\n", shellQuote(name), shellQuote(folders[index]))
+		quotedNames = append(quotedNames, shellQuote(name))
 	}
-	return nil
+	var functions = strings.Join(quotedNames, " ")
+	return "_bm_base() {\n" +
+		"  case $1 in\n" +
+		cases +
+		"    *) return 1 ;;\n" +
+		"  esac\n" +
+		"}\n" +
+		"if [ -n \"${ZSH_VERSION-}\" ]; then\n" +
+		"  _bm_subpaths() {\n" +
+		"    local base\n" +
+		"    base=$(_bm_base \"$words[1]\") || return 1\n" +
+		"    _path_files -W \"$base\" -/\n" +
+		"  }\n" +
+		"  (( $+functions[compdef] )) && compdef _bm_subpaths " + functions + "\n" +
+		"elif [ -n \"${BASH_VERSION-}\" ]; then\n" +
+		"  _bm_subpaths() {\n" +
+		"    local base cur=\"${COMP_WORDS[COMP_CWORD]}\" entry IFS=$'\\n'\n" +
+		"    base=$(_bm_base \"$1\") || return 1\n" +
+		"    COMPREPLY=()\n" +
+		"    for entry in $(compgen -d -S / -- \"$base/$cur\"); do\n" +
+		"      COMPREPLY+=(\"${entry#\"$base\"/}\")\n" +
+		"    done\n" +
+		"    compopt -o nospace 2>/dev/null\n" +
+		"  }\n" +
+		"  complete -F _bm_subpaths " + functions + "\n" +
+		"fi\n"
 }
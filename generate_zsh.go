@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerGenerator(generatorFunc{
+		name:        "zsh",
+		description: "zsh named directories (~abbreviation) with completion, aliases for files and commands",
+		generate:    generateZsh,
+		optIn:       true,
+	})
+}
+
+const namedDirectoryCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-"
+
+// ~name only works when the name is made of these characters
+var checkNamedDirectory = func(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("named directory is empty")
+	}
+	for _, char := range name {
+		if !strings.ContainsRune(namedDirectoryCharacters, char) {
+			return fmt.Errorf("named directory %s contains %q, only letters, digits, _ and - are allowed", name, char)
+		}
+	}
+	return nil
+}
+
+/*
+folder bookmarks become named directories, so ~c/alacritty works wherever zsh takes a path
+the completion for ~ lists the bookmarks first, then falls back to the usual users and named directories
+files and commands are the same aliases as the shell generator
+*/
+var renderZsh = func(bms []Bookmark, flags Flags) (string, error) {
+	var lines = ""
+	var entries = []string{}
+	var others = []Bookmark{}
+	for _, bm := range bms {
+		if bm.typ != "dir" {
+			others = append(others, bm)
+			continue
+		}
+		if err := checkNamedDirectory(bm.abbreviation); err != nil {
+			return "", fmt.Errorf("cannot create a zsh named directory for %s: %s", bm.abbreviation, err.Error())
+		}
+		var dir = resolve(bm.path, flags)
+		var line = fmt.Sprintf("hash -d %s=%s\n", bm.abbreviation, shellQuote(dir))
+		log.Debugln(line)
+		lines += line
+		entries = append(entries, "    "+singleQuote(bm.abbreviation+":"+dir)+"\n")
+	}
+
+	var aliases, err = renderShellAliases(others, flags)
+	if err != nil {
+		return "", err
+	}
+	lines += aliases
+
+	if len(entries) != 0 {
+		lines += "_bm_named_directories() {\n" +
+			"  local -a bookmarks\n" +
+			"  bookmarks=(\n" + strings.Join(entries, "") + "  )\n" +
+			"  _describe -t named-directories 'bookmark' bookmarks -S / || _tilde \"$@\"\n" +
+			"}\n" +
+			"(( $+functions[compdef] )) && compdef _bm_named_directories -tilde-\n"
+	}
+	return lines, nil
+}
+
+// with --zsh-standalone the file only has the bookmarks, otherwise they go to a section of it
+var generateZsh = func(bms []Bookmark, flags Flags) error {
+	var lines, err = renderZsh(bms, flags)
+	if err != nil {
+		return err
+	}
+	if flags.zshStandalone {
+		return writeFileAtomic(flags.zshFile, []byte("# Generated by BOOKMARKER, changes to this file will be overwritten\n\n"+lines))
+	}
+	return updateManagedBlock(flags.zshFile, lines, flags)
+}
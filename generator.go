@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// a generator receives the parsed bookmarks and turns them into something
+// another application understands (shell aliases, lf mappings...)
+type Generator interface {
+	Name() string
+	Description() string
+	Generate(bms []Bookmark, flags Flags) error
+	// generators for programs not everyone has only run when they are asked for with --generators
+	DefaultEnabled() bool
+}
+
+// generatorFunc lets a plain generate function act as a Generator
+type generatorFunc struct {
+	name        string
+	description string
+	generate    func(bms []Bookmark, flags Flags) error
+	optIn       bool
+}
+
+func (g generatorFunc) Name() string {
+	return g.name
+}
+
+func (g generatorFunc) Description() string {
+	return g.description
+}
+
+func (g generatorFunc) Generate(bms []Bookmark, flags Flags) error {
+	return g.generate(bms, flags)
+}
+
+func (g generatorFunc) DefaultEnabled() bool {
+	return !g.optIn
+}
+
+// every generator adds itself to this list in an init function, so the list is in the order of the file names
+// every generator runs even when one before it fails, so the order does not matter
+var generators = []Generator{}
+
+var registerGenerator = func(generator Generator) {
+	for _, g := range generators {
+		if g.Name() == generator.Name() {
+			panic("generator " + generator.Name() + " is registered twice")
+		}
+	}
+	generators = append(generators, generator)
+}
+
+var findGenerator = func(name string) (Generator, bool) {
+	for _, g := range generators {
+		if g.Name() == name {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// picks the generators that should run, using the --generators and --skip flags
+// an empty selection means every generator that is enabled by default
+var selectGenerators = func(flags Flags) ([]Generator, error) {
+	for _, name := range append(append([]string{}, flags.generators...), flags.skipGenerators...) {
+		if _, exists := findGenerator(name); !exists {
+			return nil, fmt.Errorf("unknown generator %s (available: %s)", name, strings.Join(generatorNames(), ", "))
+		}
+	}
+
+	var selected = []Generator{}
+	for _, g := range generators {
+		if len(flags.generators) == 0 && !g.DefaultEnabled() {
+			continue
+		}
+		if len(flags.generators) != 0 && !contains(flags.generators, g.Name()) {
+			continue
+		}
+		if contains(flags.skipGenerators, g.Name()) {
+			continue
+		}
+		selected = append(selected, g)
+	}
+	return selected, nil
+}
+
+var generatorNames = func() []string {
+	var names = make([]string, 0, len(generators))
+	for _, g := range generators {
+		names = append(names, g.Name())
+	}
+	return names
+}
+
+// reads and parses the input file, then feeds the bookmarks to every selected generator
+var generateAll = func(flags Flags) error {
+	var bms, err = readBookmarks(flags)
+	if err != nil {
+		return err
+	}
+
+	var selected, selectErr = selectGenerators(flags)
+	if selectErr != nil {
+		return selectErr
+	}
+	// one generator failing does not stop the files of the others from being written
+	var problems = []string{}
+	for _, generator := range selected {
+		log.Debugln("running generator", generator.Name())
+		if err := generator.Generate(bms, flags); err != nil {
+			problems = append(problems, fmt.Sprintf("%s generator: %s", generator.Name(), err.Error()))
+		}
+	}
+	if len(problems) != 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestGenerators(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("generator selection", func() {
+		g.It("selects the default generators when nothing is asked for", func() {
+			var selected, err = selectGenerators(Flags{})
+			g.Assert(err).IsNil()
+			var names = []string{}
+			for _, generator := range selected {
+				names = append(names, generator.Name())
+			}
+			g.Assert(names).Equal([]string{"lf", "shell"})
+		})
+
+		g.It("selects opt-in generators when they are asked for", func() {
+			var selected, err = selectGenerators(Flags{generators: []string{"zsh", "fish"}})
+			g.Assert(err).IsNil()
+			g.Assert(len(selected)).Equal(2)
+			g.Assert(selected[0].Name()).Equal("fish")
+			g.Assert(selected[1].Name()).Equal("zsh")
+		})
+
+		g.It("only selects the generators asked for", func() {
+			var selected, err = selectGenerators(Flags{generators: []string{"lf"}})
+			g.Assert(err).IsNil()
+			g.Assert(len(selected)).Equal(1)
+			g.Assert(selected[0].Name()).Equal("lf")
+		})
+
+		g.It("skips generators", func() {
+			var selected, err = selectGenerators(Flags{skipGenerators: []string{"lf"}})
+			g.Assert(err).IsNil()
+			for _, generator := range selected {
+				g.Assert(generator.Name() == "lf").IsFalse()
+			}
+		})
+
+		g.It("rejects unknown generators", func() {
+			var _, err = selectGenerators(Flags{generators: []string{"nope"}})
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("runs every generator when one fails", func() {
+			var original = generators
+			defer func() { generators = original }()
+			var ran = []string{}
+			var fake = func(name string, err error) Generator {
+				return generatorFunc{name: name, generate: func(bms []Bookmark, flags Flags) error {
+					ran = append(ran, name)
+					return err
+				}}
+			}
+			generators = []Generator{fake("first", errors.New("broken")), fake("second", nil)}
+
+			AppFs = afero.NewMemMapFs()
+			afero.WriteFile(AppFs, "/list", []byte("t /tmp/\n"), 0644)
+			var err = generateAll(Flags{bookmarkFile: "/list", missingPolicy: "skip"})
+			g.Assert(err.Error()).Equal("first generator: broken")
+			g.Assert(ran).Equal([]string{"first", "second"})
+		})
+	})
+}
@@ -3,12 +3,16 @@ module bookmarker
 go 1.18
 
 require (
-	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf // indirect
+	github.com/BurntSushi/toml v1.2.1
+	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
+	github.com/sirupsen/logrus v1.8.1
+	github.com/spf13/afero v1.8.2
+	github.com/spf13/cobra v1.4.0
+	github.com/spf13/pflag v1.0.5
+)
+
+require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
-	github.com/spf13/afero v1.8.2 // indirect
-	github.com/spf13/cobra v1.4.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
 	golang.org/x/text v0.3.4 // indirect
 )
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// the generators that can print code for a shell instead of writing a file
+var shellRenderers = map[string]func(bms []Bookmark, flags Flags) (string, error){
+	"bash": renderShellAliases,
+	"zsh":  renderZsh,
+	"fish": renderFish,
+}
+
+var shellNames = func() []string {
+	var names = []string{}
+	for name := range shellRenderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// changes when bm is upgraded or built again, the code it prints may have changed with it
+var buildID = func() string {
+	var id = ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		id = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+				id += " " + setting.Value
+			}
+		}
+	}
+	// a build from a changed tree has the same revision, but not the same executable
+	if executable, err := os.Executable(); err == nil {
+		if info, err := os.Stat(executable); err == nil {
+			id += fmt.Sprintf(" %d %d", info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return id
+}
+
+/*
+the cache is only valid for the same build of bm, the same flags and the same $EDITOR,
+the same bookmark file and included files, the same include? files missing, and the same values of the environment variables they use
+*/
+var initCacheKey = func(shell string, flags Flags, inputs bookmarkInputs) (string, error) {
+	// debug output does not change the code
+	var keyFlags = flags
+	keyFlags.debug = false
+	var hash = fnv.New64a()
+	fmt.Fprintf(hash, "%s\n%#v %s\n", buildID(), keyFlags, os.Getenv("EDITOR"))
+	for _, file := range append([]string{flags.bookmarkFile}, inputs.Includes...) {
+		var info, err = AppFs.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", file, info.ModTime().UnixNano(), info.Size())
+	}
+	for _, file := range inputs.Missing {
+		var _, err = AppFs.Stat(file)
+		fmt.Fprintf(hash, "%s missing %t\n", file, errors.Is(err, fs.ErrNotExist))
+	}
+	for _, name := range inputs.Environment {
+		var value, exists = os.LookupEnv(name)
+		fmt.Fprintf(hash, "$%s %t %s\n", name, exists, value)
+	}
+	return fmt.Sprintf("# bm init %s %x", shell, hash.Sum64()), nil
+}
+
+const initCacheInputs = "# inputs "
+
+/*
+the cache starts with the key and the inputs, as they are only known after parsing:
+# bm init bash 1f2e3d4c5b6a7980
+# inputs {"includes":["/home/me/team.list"],"missing":["/home/me/local.list"],"environment":["WORKDIR"]}
+*/
+var readInitCache = func(shell string, flags Flags) (string, bool) {
+	var cached, file, err = readTextFromFile(initCacheFile(shell, flags))
+	if file != nil {
+		file.Close()
+	}
+	var lines = strings.SplitN(cached, "\n", 3)
+	if err != nil || len(lines) != 3 || !strings.HasPrefix(lines[1], initCacheInputs) {
+		return "", false
+	}
+	var inputs bookmarkInputs
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], initCacheInputs)), &inputs); err != nil {
+		return "", false
+	}
+	// an included file that is gone makes the cache old too
+	var key, keyErr = initCacheKey(shell, flags, inputs)
+	if keyErr != nil || key != lines[0] {
+		return "", false
+	}
+	return lines[2], true
+}
+
+var initCacheFile = func(shell string, flags Flags) string {
+	return xdgBaseDirectories(flags.homePath).cacheFile("bookmarker", "init."+shell)
+}
+
+// the code for eval "$(bm init <shell>)", from the cache when the bookmark file has not changed
+// it also has a bm function, so bm cd can change the folder of the shell
+var renderInit = func(shell string, flags Flags) (string, error) {
+	var render, exists = shellRenderers[shell]
+	if !exists {
+		return "", fmt.Errorf("unknown shell %s (available: %s)", shell, strings.Join(shellNames(), ", "))
+	}
+	// an rc file can run before $EDITOR is set, that should not cost the shell its bookmarks
+	flags.editorAtRuntime = true
+
+	if flags.initCache {
+		if _, err := AppFs.Stat(flags.bookmarkFile); err != nil {
+			return "", err
+		}
+		if code, valid := readInitCache(shell, flags); valid {
+			log.Debugln("using the cached code for", shell)
+			return code, nil
+		}
+	}
+
+	var bms, inputs, err = readBookmarkFiles(flags)
+	if err != nil {
+		return "", err
+	}
+	code, err := render(bms, flags)
+	if err != nil {
+		return "", err
+	}
+	code += renderShellWrapper(shell, flags)
+
+	if flags.initCache {
+		var cacheFile = initCacheFile(shell, flags)
+		var key, keyErr = initCacheKey(shell, flags, inputs)
+		var inputsJSON, _ = json.Marshal(inputs)
+		// a cache that cannot be written only makes the next start up slower
+		if keyErr != nil {
+			log.Warnln("cannot write the cache:", keyErr.Error())
+		} else if err := writeFileAtomic(cacheFile, []byte(key+"\n"+initCacheInputs+string(inputsJSON)+"\n"+code)); err != nil {
+			log.Warnln("cannot write the cache:", err.Error())
+		}
+	}
+	return code, nil
+}
+
+var newInitCommand = func(flags *Flags) *cobra.Command {
+	var initCmd = &cobra.Command{
+		Use:       "init <" + strings.Join(shellNames(), "|") + ">",
+		Short:     "Print the bookmarks as shell code, add eval \"$(bm init zsh)\" to your shell rc file to load them",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: shellNames(),
+		Run: func(cmd *cobra.Command, args []string) {
+			// stdout is going to be evaluated by the shell, so nothing else can go there, warnings already go to stderr
+			// one missing path should not leave the shell without any bookmarks
+			skipMissingByDefault(cmd, flags)
+			var code, err = renderInit(args[0], *flags)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			fmt.Print(code)
+		},
+	}
+	initCmd.Flags().BoolVar(&flags.initCache, "cache", false, "Reuse the code from the last time until the bookmark file changes, which makes shell start up faster")
+	return initCmd
+}
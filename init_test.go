@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestInit(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("bm init", func() {
+		var flags = Flags{
+			homePath:               "/home/me",
+			bookmarkFile:           "/home/me/.config/bookmarker/list",
+			editor:                 "vim",
+			shellAliasFolderPrefix: "cd",
+			shellAliasFilePrefix:   "cf",
+		}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/.config/bookmarker", 0755)
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("c .config/\n!ll ls -l\n"), 0644)
+		})
+
+		g.It("prints the code of the shell", func() {
+			var code, err = renderInit("bash", flags)
+			g.Assert(err).IsNil()
+			g.Assert(code).Equal("alias cdc='cd /home/me/.config'\nalias ll='ls -l'\n" + renderShellWrapper("bash", flags))
+
+			code, err = renderInit("fish", flags)
+			g.Assert(err).IsNil()
+			g.Assert(strings.HasSuffix(code, "abbr --add --global -- ll 'ls -l'\n"+renderShellWrapper("fish", flags))).IsTrue()
+
+			_, err = renderInit("tcsh", flags)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("looks up $EDITOR when it is not known yet", func() {
+			// restored after the test
+			t.Setenv("EDITOR", "")
+			os.Unsetenv("EDITOR")
+			afero.WriteFile(AppFs, "/home/me/notes", []byte{}, 0644)
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("n notes\n"), 0644)
+			var noEditor = flags
+			noEditor.editor = ""
+			var code, err = renderInit("bash", noEditor)
+			g.Assert(err).IsNil()
+			g.Assert(strings.HasPrefix(code, shellRuntimeEditor+"alias cfn='_bm_editor /home/me/notes'\n")).IsTrue()
+			code, err = renderInit("zsh", noEditor)
+			g.Assert(err).IsNil()
+			g.Assert(strings.Contains(code, shellRuntimeEditor)).IsTrue()
+		})
+
+		g.It("leaves out missing paths unless --missing is given", func() {
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("c .config/\nx gone/\n"), 0644)
+			var initFlags = flags
+			initFlags.missingPolicy = "error"
+			var initCmd = newInitCommand(&initFlags)
+			initCmd.Flags().StringVar(&initFlags.missingPolicy, "missing", "error", "")
+			skipMissingByDefault(initCmd, &initFlags)
+			var code, err = renderInit("bash", initFlags)
+			g.Assert(err).IsNil()
+			g.Assert(code).Equal("alias cdc='cd /home/me/.config'\n" + renderShellWrapper("bash", flags))
+
+			initFlags.missingPolicy = "error"
+			initCmd.Flags().Set("missing", "error")
+			skipMissingByDefault(initCmd, &initFlags)
+			g.Assert(initFlags.missingPolicy).Equal("error")
+		})
+
+		g.It("uses the cache until the bookmark file changes", func() {
+			t.Setenv("XDG_CACHE_HOME", "")
+			var cacheFlags = flags
+			cacheFlags.initCache = true
+			var code, err = renderInit("bash", cacheFlags)
+			g.Assert(err).IsNil()
+
+			// the cache is what gets printed while the bookmark file stays the same
+			var cacheFile = path.Join("/home/me", ".cache", "bookmarker", "init.bash")
+			var cached, _ = afero.ReadFile(AppFs, cacheFile)
+			g.Assert(strings.HasSuffix(string(cached), "\n"+code)).IsTrue()
+			afero.WriteFile(AppFs, cacheFile, []byte(strings.Replace(string(cached), "cdc", "cached", 1)), 0644)
+			code, _ = renderInit("bash", cacheFlags)
+			g.Assert(strings.HasPrefix(code, "alias cached='cd /home/me/.config'\nalias ll='ls -l'\n")).IsTrue()
+
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("c .config/\n"), 0644)
+			AppFs.Chtimes(flags.bookmarkFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+			code, _ = renderInit("bash", cacheFlags)
+			g.Assert(code).Equal("alias cdc='cd /home/me/.config'\n" + renderShellWrapper("bash", flags))
+		})
+
+		g.It("makes a new cache for a new build of bm", func() {
+			t.Setenv("XDG_CACHE_HOME", "")
+			var cacheFlags = flags
+			cacheFlags.initCache = true
+			var original = buildID
+			defer func() { buildID = original }()
+			var before, _ = initCacheKey("bash", cacheFlags, bookmarkInputs{})
+			buildID = func() string { return "v2" }
+			var after, _ = initCacheKey("bash", cacheFlags, bookmarkInputs{})
+			g.Assert(before == after).IsFalse()
+		})
+
+		g.It("makes a new cache when an environment variable the file uses changes", func() {
+			t.Setenv("XDG_CACHE_HOME", "")
+			t.Setenv("BM_TEST_WORKDIR", ".config")
+			var cacheFlags = flags
+			cacheFlags.initCache = true
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("w $BM_TEST_WORKDIR/\n"), 0644)
+			var code, _ = renderInit("bash", cacheFlags)
+			g.Assert(strings.HasPrefix(code, "alias cdw='cd /home/me/.config'\n")).IsTrue()
+
+			AppFs.MkdirAll("/home/me/work", 0755)
+			t.Setenv("BM_TEST_WORKDIR", "work")
+			code, _ = renderInit("bash", cacheFlags)
+			g.Assert(strings.HasPrefix(code, "alias cdw='cd /home/me/work'\n")).IsTrue()
+		})
+
+		g.It("makes a new cache when an included file changes", func() {
+			t.Setenv("XDG_CACHE_HOME", "")
+			var cacheFlags = flags
+			cacheFlags.initCache = true
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("include? extra\n"), 0644)
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/extra", []byte("c .config/\n"), 0644)
+			var code, _ = renderInit("bash", cacheFlags)
+			g.Assert(strings.HasPrefix(code, "alias cdc=")).IsTrue()
+
+			AppFs.Remove("/home/me/.config/bookmarker/extra")
+			code, _ = renderInit("bash", cacheFlags)
+			g.Assert(code).Equal(renderShellWrapper("bash", flags))
+
+			// and when a missing include? file shows up
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/extra", []byte("c .config/\n"), 0644)
+			code, _ = renderInit("bash", cacheFlags)
+			g.Assert(strings.HasPrefix(code, "alias cdc=")).IsTrue()
+		})
+	})
+}
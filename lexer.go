@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+)
+
+// a word of a line in the bookmark file, with quotes and escapes already removed
+type token struct {
+	text string
+	// byte offsets into the line, end is exclusive
+	// line[start:end] is the token exactly like how it is written
+	start int
+	end   int
+}
+
+// the column is where the problem starts, counting from 0
+type lexError struct {
+	column  int
+	message string
+}
+
+func (e *lexError) Error() string {
+	return e.message
+}
+
+func isBlank(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\r' || char == '\n'
+}
+
+// finds the value of $NAME and ${NAME} while lexing
+type variableLookup func(name string) (string, bool)
+
+var isNameCharacter = func(char byte, first bool) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (!first && char >= '0' && char <= '9')
+}
+
+var isVariableName = func(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isNameCharacter(name[i], i == 0) {
+			return false
+		}
+	}
+	return len(name) != 0
+}
+
+/*
+reads the variable at line[index], which is a $, and returns its value and how long it is in the line
+$NAME, ${NAME} and ${NAME:-default}, where the default is used when the variable is empty or not defined
+a default is taken as it is, so one that starts with ~ is an error instead of a folder called ~
+a $ that is not followed by a name is just a $
+*/
+var expandVariable = func(line string, index int, lookup variableLookup) (string, int, *lexError) {
+	if index+1 < len(line) && line[index+1] == '{' {
+		var closing = strings.IndexByte(line[index:], '}')
+		if closing == -1 {
+			return "", 0, &lexError{column: index, message: "${ is never closed"}
+		}
+		var name, fallback, hasFallback = strings.Cut(line[index+2:index+closing], ":-")
+		if !isVariableName(name) {
+			return "", 0, &lexError{column: index, message: "bad variable name " + name}
+		}
+		if strings.HasPrefix(fallback, "~") {
+			return "", 0, &lexError{column: index, message: "~ is not expanded in the default of ${" + name + "}, use $HOME instead"}
+		}
+		var value, exists = lookup(name)
+		if hasFallback && len(value) == 0 {
+			return fallback, closing + 1, nil
+		}
+		if !exists {
+			return "", 0, &lexError{column: index, message: "variable " + name + " is not defined"}
+		}
+		return value, closing + 1, nil
+	}
+
+	var end = index + 1
+	for end < len(line) && isNameCharacter(line[end], end == index+1) {
+		end++
+	}
+	if end == index+1 {
+		return "$", 1, nil
+	}
+	var value, exists = lookup(line[index+1 : end])
+	if !exists {
+		return "", 0, &lexError{column: index, message: "variable " + line[index+1:end] + " is not defined"}
+	}
+	return value, end - index, nil
+}
+
+/*
+splits a line into tokens, a bit like what a POSIX shell does:
+- any amount of spaces and tabs separates tokens
+- 'single quotes' keep everything inside as it is
+- "double quotes" keep everything inside, except \ can escape " \ $ and `
+- outside of quotes, \ makes the next character a normal character
+- a # at the start of a token comments out the rest of the line
+*/
+var lexLine = func(line string) ([]token, *lexError) {
+	return lexLineExpanding(line, nil)
+}
+
+// like lexLine, but $variables outside of single quotes are replaced by what lookup gives, unless lookup is nil
+var lexLineExpanding = func(line string, lookup variableLookup) ([]token, *lexError) {
+	var tokens = []token{}
+	var index = 0
+	for index < len(line) {
+		// skip the spaces between tokens
+		if isBlank(line[index]) {
+			index++
+			continue
+		}
+		if line[index] == '#' {
+			break
+		}
+
+		var start = index
+		var text strings.Builder
+		for index < len(line) && !isBlank(line[index]) {
+			switch line[index] {
+			case '\'':
+				var closing = strings.IndexByte(line[index+1:], '\'')
+				if closing == -1 {
+					return nil, &lexError{column: index, message: "single quote is never closed"}
+				}
+				text.WriteString(line[index+1 : index+1+closing])
+				index += closing + 2
+			case '"':
+				var quoteStart = index
+				index++
+				for {
+					if index >= len(line) {
+						return nil, &lexError{column: quoteStart, message: "double quote is never closed"}
+					}
+					if line[index] == '"' {
+						index++
+						break
+					}
+					if line[index] == '\\' && index+1 < len(line) && strings.IndexByte("\"\\$`", line[index+1]) != -1 {
+						index++
+					} else if line[index] == '$' && lookup != nil {
+						var value, length, err = expandVariable(line, index, lookup)
+						if err != nil {
+							return nil, err
+						}
+						text.WriteString(value)
+						index += length
+						continue
+					}
+					text.WriteByte(line[index])
+					index++
+				}
+			case '\\':
+				if index+1 >= len(line) {
+					return nil, &lexError{column: index, message: "nothing to escape after \\"}
+				}
+				text.WriteByte(line[index+1])
+				index += 2
+			case '$':
+				if lookup == nil {
+					text.WriteByte('$')
+					index++
+					continue
+				}
+				var value, length, err = expandVariable(line, index, lookup)
+				if err != nil {
+					return nil, err
+				}
+				text.WriteString(value)
+				index += length
+			default:
+				text.WriteByte(line[index])
+				index++
+			}
+		}
+		tokens = append(tokens, token{text: text.String(), start: start, end: index})
+	}
+	return tokens, nil
+}
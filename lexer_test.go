@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestLexer(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("lexLine works", func() {
+		var texts = func(tokens []token) []string {
+			var result = []string{}
+			for _, tok := range tokens {
+				result = append(result, tok.text)
+			}
+			return result
+		}
+
+		g.It("splits on any amount of whitespace", func() {
+			var tokens, err = lexLine(" a \t b   c ")
+			g.Assert(err == nil).IsTrue()
+			g.Assert(texts(tokens)).Equal([]string{"a", "b", "c"})
+			g.Assert(tokens[1]).Equal(token{text: "b", start: 5, end: 6})
+		})
+
+		g.It("handles quotes and escapes", func() {
+			var tokens, err = lexLine(`'it"s' "say \"hi\" \n" a\ b c'd'"e"`)
+			g.Assert(err == nil).IsTrue()
+			g.Assert(texts(tokens)).Equal([]string{`it"s`, `say "hi" \n`, "a b", "cde"})
+		})
+
+		g.It("stops at comments that start a token", func() {
+			var tokens, _ = lexLine("a b#c # d e")
+			g.Assert(texts(tokens)).Equal([]string{"a", "b#c"})
+			tokens, _ = lexLine("# only a comment")
+			g.Assert(len(tokens)).Equal(0)
+		})
+
+		g.It("reports where the broken quote starts", func() {
+			var _, err = lexLine(`a "bc`)
+			g.Assert(err).Equal(&lexError{column: 2, message: "double quote is never closed"})
+			_, err = lexLine(`a b\`)
+			g.Assert(err).Equal(&lexError{column: 3, message: "nothing to escape after \\"})
+		})
+
+		g.It("expands variables outside of single quotes", func() {
+			var lookup = func(name string) (string, bool) {
+				var values = map[string]string{"a": "1", "EMPTY": ""}
+				var value, exists = values[name]
+				return value, exists
+			}
+			var tokens, err = lexLineExpanding(`$a/${a}x "$a b" '$a' \$a ${b:-two} ${EMPTY:-none} $ $/`, lookup)
+			g.Assert(err == nil).IsTrue()
+			g.Assert(texts(tokens)).Equal([]string{"1/1x", "1 b", "$a", "$a", "two", "none", "$", "$/"})
+
+			_, err = lexLineExpanding("x ${nope}", lookup)
+			g.Assert(err).Equal(&lexError{column: 2, message: "variable nope is not defined"})
+			_, err = lexLineExpanding("x $nope/y", lookup)
+			g.Assert(err).Equal(&lexError{column: 2, message: "variable nope is not defined"})
+			_, err = lexLineExpanding("${a", lookup)
+			g.Assert(err).Equal(&lexError{column: 0, message: "${ is never closed"})
+			_, err = lexLineExpanding("${1a}", lookup)
+			g.Assert(err).Equal(&lexError{column: 0, message: "bad variable name 1a"})
+			_, err = lexLineExpanding("x ${nope:-~/work}/", lookup)
+			g.Assert(err).Equal(&lexError{column: 2, message: "~ is not expanded in the default of ${nope}, use $HOME instead"})
+
+			tokens, _ = lexLine("$a")
+			g.Assert(texts(tokens)).Equal([]string{"$a"})
+		})
+	})
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// the mappings lf has before lfrc is read
+var lfDefaultMaps = map[string]string{
+	"k": "up", "<up>": "up", "<c-u>": "half-up", "<c-b>": "page-up", "<pgup>": "page-up", "<c-y>": "scroll-up",
+	"j": "down", "<down>": "down", "<c-d>": "half-down", "<c-f>": "page-down", "<pgdn>": "page-down", "<c-e>": "scroll-down",
+	"h": "updir", "<left>": "updir", "l": "open", "<right>": "open", "]": "jump-next", "[": "jump-prev",
+	"gg": "top", "<home>": "top", "G": "bottom", "<end>": "bottom", "H": "high", "M": "middle", "L": "low",
+	"<space>": "toggle", "v": "invert", "u": "unselect", "y": "copy", "d": "cut", "p": "paste", "c": "clear",
+	"<c-l>": "redraw", "<c-r>": "reload", ":": "read", "r": "rename", "q": "quit",
+	"$": "shell", "%": "shell-pipe", "!": "shell-wait", "&": "shell-async",
+	"f": "find", "F": "find-back", ";": "find-next", ",": "find-prev",
+	"/": "search", "?": "search-back", "n": "search-next", "N": "search-prev",
+	"m": "mark-save", "'": "mark-load", "\"": "mark-remove", "t": "tag-toggle",
+	"e": "$EDITOR", "i": "$PAGER", "w": "$SHELL",
+	"zh": "set hidden!", "zr": "set reverse!", "zn": "set info", "zs": "set info size", "zt": "set info time", "za": "set info size:time",
+	"sn": "sort natural", "ss": "sort size", "st": "sort time", "sa": "sort atime", "sc": "sort ctime", "se": "sort ext",
+	"gh": "cd ~",
+}
+
+// splits a key sequence like lf does: <c-f> and <space> are one key, everything else is one character
+var lfKeys = func(sequence string) []string {
+	var keys = []string{}
+	for i := 0; i < len(sequence); {
+		if end := strings.IndexByte(sequence[i:], '>'); sequence[i] == '<' && end > 1 {
+			keys = append(keys, sequence[i:i+end+1])
+			i += end + 1
+			continue
+		}
+		var _, size = utf8.DecodeRuneInString(sequence[i:])
+		keys = append(keys, sequence[i:i+size])
+		i += size
+	}
+	return keys
+}
+
+// a key sequence and where it comes from: a bookmark, a line of lfrc or the defaults of lf
+type lfBinding struct {
+	keys     string
+	bookmark string
+	line     int
+	command  string
+	// set for the generated mappings of another profile, which bm writes again on every run
+	fromProfile bool
+	profile     string
+}
+
+func (b lfBinding) describe() string {
+	if b.fromProfile && len(b.profile) == 0 {
+		return fmt.Sprintf("map %s of the default profile", b.keys)
+	} else if b.fromProfile {
+		return fmt.Sprintf("map %s of profile %s", b.keys, b.profile)
+	} else if len(b.bookmark) != 0 {
+		return fmt.Sprintf("%s (bookmark %s)", b.keys, b.bookmark)
+	} else if b.line != 0 {
+		return fmt.Sprintf("map %s on line %d of lfrc", b.keys, b.line)
+	}
+	return fmt.Sprintf("lf's default %s (%s)", b.keys, b.command)
+}
+
+// the name of the profile of a start marker, like managedBlockMarkers writes it
+var managedBlockProfile = func(marker string) string {
+	var name = strings.TrimSuffix(strings.TrimPrefix(marker, managedBlockStart), "###")
+	name = strings.TrimSpace(name)
+	return strings.TrimSuffix(strings.TrimPrefix(name, "("), ")")
+}
+
+/*
+the mappings of lfrc, without the ones in our own section
+the sections of other profiles are marked, they are not the user's to remove from lfrc
+map with only the keys removes a mapping, so the defaults it removes are returned too
+*/
+var readLfMaps = func(text string, flags Flags) ([]lfBinding, []string) {
+	var start, _ = managedBlockMarkers(flags.profile)
+	var maps = []lfBinding{}
+	var unmapped = []string{}
+	var generated, otherProfile, inCommand = false, false, false
+	var profile string
+	for index, line := range strings.Split(text, "\n") {
+		var trimmed = strings.TrimSpace(line)
+		switch {
+		case trimmed == start:
+			generated = true
+		case strings.HasPrefix(trimmed, managedBlockStart):
+			otherProfile = true
+			profile = managedBlockProfile(trimmed)
+		case strings.HasPrefix(trimmed, managedBlockEnd):
+			generated, otherProfile = false, false
+		case generated:
+		case inCommand:
+			// the body of a cmd between {{ and }} is a shell script, not lf commands
+			inCommand = !strings.HasPrefix(trimmed, "}}")
+		case strings.HasSuffix(trimmed, "{{"):
+			inCommand = true
+		default:
+			var tokens, lexErr = lexLine(line)
+			if lexErr != nil || len(tokens) < 2 || tokens[0].text != "map" {
+				continue
+			}
+			if len(tokens) == 2 {
+				unmapped = append(unmapped, tokens[1].text)
+				continue
+			}
+			var binding = lfBinding{keys: tokens[1].text, line: index + 1, command: line[tokens[2].start:tokens[len(tokens)-1].end]}
+			if otherProfile {
+				binding.line = 0
+				binding.fromProfile = true
+				binding.profile = profile
+			}
+			maps = append(maps, binding)
+		}
+	}
+	return maps, unmapped
+}
+
+// one level of the prefix tree, every key is one edge
+type lfKeyNode struct {
+	children map[string]*lfKeyNode
+	bindings []lfBinding
+}
+
+func (n *lfKeyNode) insert(binding lfBinding) {
+	var node = n
+	for _, key := range lfKeys(binding.keys) {
+		if node.children[key] == nil {
+			node.children[key] = &lfKeyNode{children: map[string]*lfKeyNode{}}
+		}
+		node = node.children[key]
+	}
+	node.bindings = append(node.bindings, binding)
+}
+
+// every binding below the node, not counting the node itself
+func (n *lfKeyNode) descendants() []lfBinding {
+	var bindings = []lfBinding{}
+	for _, key := range sortedKeys(n.children) {
+		var child = n.children[key]
+		bindings = append(bindings, child.bindings...)
+		bindings = append(bindings, child.descendants()...)
+	}
+	return bindings
+}
+
+type lfConflict struct {
+	severity Severity
+	message  string
+}
+
+/*
+puts the generated key sequences, the mappings of lfrc and the defaults of lf into a prefix tree
+two bindings conflict when they end on the same node, or when one ends on the way to the other:
+lf cannot know if gc is meant as itself or as the start of gca, so one of them cannot be used
+only conflicts with a bookmark are reported, the rest of lfrc is up to the user
+a bookmark taking over a mapping from lfrc is an error, everything else is a warning (an error with --lf-strict)
+*/
+var findLfConflicts = func(bms []Bookmark, lfrc string, flags Flags) []lfConflict {
+	var root = &lfKeyNode{children: map[string]*lfKeyNode{}}
+	var userMaps, unmapped = readLfMaps(lfrc, flags)
+	var userKeys = map[string]bool{}
+	for _, binding := range userMaps {
+		userKeys[binding.keys] = true
+		root.insert(binding)
+	}
+	for keys, command := range lfDefaultMaps {
+		// a mapping in lfrc replaces the default
+		if !userKeys[keys] && !contains(unmapped, keys) {
+			root.insert(lfBinding{keys: keys, command: command})
+		}
+	}
+	for _, bm := range bms {
+		for _, keys := range lfBookmarkKeys(bm, flags) {
+			root.insert(lfBinding{keys: keys, bookmark: bm.abbreviation})
+		}
+	}
+
+	var conflicts = []lfConflict{}
+	var report = func(a lfBinding, b lfBinding, same bool) {
+		if len(a.bookmark) == 0 && len(b.bookmark) == 0 {
+			return
+		}
+		// the bookmark first, it is the one the message is about
+		if len(a.bookmark) == 0 {
+			a, b = b, a
+		}
+		var severity = SeverityWarning
+		if b.line != 0 || flags.lfStrict {
+			severity = SeverityError
+		}
+		var message string
+		switch {
+		case same && b.fromProfile:
+			message = fmt.Sprintf("%s clashes with %s, the profiles share lfrc, rename one of the bookmarks or give the profiles different --lf-mapping-prefix", a.describe(), b.describe())
+		case b.fromProfile:
+			message = fmt.Sprintf("%s and %s start with the same keys, the profiles share lfrc, rename one of the bookmarks or give the profiles different --lf-mapping-prefix", a.describe(), b.describe())
+		case same && len(b.bookmark) != 0:
+			message = fmt.Sprintf("%s is mapped twice by bookmark %s, only the last one is used, remove one of them", a.keys, a.bookmark)
+		case same && b.line != 0:
+			message = fmt.Sprintf("%s replaces %s, rename the bookmark, change --lf-mapping-prefix or remove the line from lfrc", a.describe(), b.describe())
+		case same:
+			message = fmt.Sprintf("%s replaces %s, rename the bookmark or change --lf-mapping-prefix to keep it", a.describe(), b.describe())
+		case len(b.bookmark) != 0:
+			message = fmt.Sprintf("%s and %s start with the same keys, so lf cannot use both, rename %s or %s so neither starts with the other", a.describe(), b.describe(), a.bookmark, b.bookmark)
+		default:
+			message = fmt.Sprintf("%s and %s start with the same keys, so lf cannot use both, rename the bookmark or change --lf-mapping-prefix", a.describe(), b.describe())
+		}
+		conflicts = append(conflicts, lfConflict{severity, message})
+	}
+
+	var walk func(node *lfKeyNode)
+	walk = func(node *lfKeyNode) {
+		for i := range node.bindings {
+			for j := i + 1; j < len(node.bindings); j++ {
+				report(node.bindings[i], node.bindings[j], true)
+			}
+			for _, longer := range node.descendants() {
+				report(node.bindings[i], longer, false)
+			}
+		}
+		for _, key := range sortedKeys(node.children) {
+			walk(node.children[key])
+		}
+	}
+	walk(root)
+	return conflicts
+}
+
+// map order is random, but the messages should come out the same every time
+var sortedKeys = func(children map[string]*lfKeyNode) []string {
+	var keys = make([]string, 0, len(children))
+	for key := range children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestLfConflicts(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("lf key sequence conflicts", func() {
+		var flags = Flags{homePath: "/home/me", lfMappingPrefix: "g"}
+		var messages = func(conflicts []lfConflict) []string {
+			var texts = []string{}
+			for _, conflict := range conflicts {
+				texts = append(texts, conflict.severity.String()+": "+conflict.message)
+			}
+			return texts
+		}
+
+		g.It("splits special keys like lf", func() {
+			g.Assert(lfKeys("g<c-f>x<")).Equal([]string{"g", "<c-f>", "x", "<"})
+			g.Assert(lfKeys("<>é")).Equal([]string{"<", ">", "é"})
+		})
+
+		g.It("finds bookmarks that start with another bookmark", func() {
+			var bookmarks = []Bookmark{
+				{typ: "dir", path: "/a", abbreviation: "c"},
+				{typ: "dir", path: "/b", abbreviation: "ca"},
+				{typ: "file", path: "/c", abbreviation: "cab"},
+				{typ: "dir", path: "/d", abbreviation: "x"},
+			}
+			g.Assert(messages(findLfConflicts(bookmarks, "", flags))).Equal([]string{
+				"warning: gc (bookmark c) and gca (bookmark ca) start with the same keys, so lf cannot use both, rename c or ca so neither starts with the other",
+			})
+
+			var strictFlags = flags
+			strictFlags.lfStrict = true
+			g.Assert(findLfConflicts(bookmarks, "", strictFlags)[0].severity).Equal(SeverityError)
+		})
+
+		g.It("compares with lf's defaults and the rest of lfrc", func() {
+			var lfrc = "set icons true\n" +
+				"map gx $lazygit\n" +
+				"map gt\n" +
+				"cmd bulk {{\n" +
+				"    map gp nothing\n" +
+				"}}\n" +
+				START_GENERATION_STRING + "\n" +
+				"map gw cd /old\n" +
+				END_GENERATION_STRING + "\n"
+			var bookmarks = []Bookmark{
+				{typ: "dir", path: "/a", abbreviation: "g"},
+				{typ: "dir", path: "/b", abbreviation: "x"},
+				{typ: "dir", path: "/c", abbreviation: "w"},
+				{typ: "dir", path: "/d", abbreviation: "p"},
+				{typ: "dir", path: "/e", abbreviation: "hx"},
+			}
+			g.Assert(messages(findLfConflicts(bookmarks, lfrc, flags))).Equal([]string{
+				"warning: gg (bookmark g) replaces lf's default gg (top), rename the bookmark or change --lf-mapping-prefix to keep it",
+				"warning: ghx (bookmark hx) and lf's default gh (cd ~) start with the same keys, so lf cannot use both, rename the bookmark or change --lf-mapping-prefix",
+				"error: gx (bookmark x) replaces map gx on line 2 of lfrc, rename the bookmark, change --lf-mapping-prefix or remove the line from lfrc",
+			})
+		})
+
+		g.It("tells clashes with another profile apart from lines of lfrc", func() {
+			var workFlags = flags
+			workFlags.profile = "work"
+			var home, _ = managedBlockMarkers("")
+			var lfrc = home + "\nmap gw cd /home/w\n" + END_GENERATION_STRING + "\n" +
+				"### Automatically generated by BOOKMARKER (play) ###\nmap gpa cd /games\n### End of BOOKMARKER generation (play)\n"
+			var bookmarks = []Bookmark{
+				{typ: "dir", path: "/work", abbreviation: "w"},
+				{typ: "dir", path: "/projects", abbreviation: "p"},
+			}
+			g.Assert(messages(findLfConflicts(bookmarks, lfrc, workFlags))).Equal([]string{
+				"warning: gp (bookmark p) and map gpa of profile play start with the same keys, the profiles share lfrc, rename one of the bookmarks or give the profiles different --lf-mapping-prefix",
+				"warning: gw (bookmark w) clashes with map gw of the default profile, the profiles share lfrc, rename one of the bookmarks or give the profiles different --lf-mapping-prefix",
+			})
+		})
+
+		g.It("stops the lf generator when a mapping of lfrc would be lost", func() {
+			AppFs = afero.NewMemMapFs()
+			var lfConfigPath = path.Join(flags.homePath, ".config", "lf", "lfrc")
+			var lfFlags = flags
+			lfFlags.lfConfigFile = lfConfigPath
+			AppFs.MkdirAll(path.Dir(lfConfigPath), os.ModeDir)
+			afero.WriteFile(AppFs, lfConfigPath, []byte("map gx $lazygit\n"), 0644)
+
+			var err = generateLfMappings([]Bookmark{{typ: "dir", path: "/b", abbreviation: "x"}}, lfFlags)
+			g.Assert(err == nil).IsFalse()
+			g.Assert(strings.Contains(err.Error(), "line 1 of lfrc")).IsTrue()
+			var text, _, _ = readTextFromFile(lfConfigPath)
+			g.Assert(text).Equal("map gx $lazygit\n")
+		})
+	})
+}
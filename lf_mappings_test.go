@@ -22,8 +22,10 @@ func TestLfMappings(t *testing.T) {
 	g.Describe("lf mappings generation works", func() {
 		var homeDir, _ = os.UserHomeDir()
 		var flags = Flags{
-			homePath: homeDir,
-			editor:   "vim",
+			homePath:        homeDir,
+			editor:          "vim",
+			lfMappingPrefix: "g",
+			lfConfigFile:    path.Join(homeDir, ".config", "lf", "lfrc"),
 		}
 
 		g.Before(func() {
@@ -137,5 +139,50 @@ func TestLfMappings(t *testing.T) {
 			var text, _, _ = readTextFromFile(path.Join(homeDir, ".config", "lf", "lfrc"))
 			g.Assert(text).Equal(want)
 		})
+
+		g.It("selects and opens files with their own prefixes", func() {
+			var fileFlags = flags
+			fileFlags.lfFileSelectPrefix = "b"
+			fileFlags.lfFileOpenPrefix = "o"
+			var bookmarks = []Bookmark{
+				{
+					typ:          "file",
+					path:         "/etc/hosts",
+					abbreviation: "h",
+				},
+				{
+					typ:          "file",
+					path:         "/tmp/it's a file.txt",
+					abbreviation: "q",
+				},
+				{
+					typ:          "dir",
+					path:         "/my docs",
+					abbreviation: "d",
+				},
+			}
+			var lines, err = renderLfMappings(bookmarks, fileFlags)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal("map bh select /etc/hosts\n" +
+				"map oh $vim /etc/hosts\n" +
+				"map bq select \"/tmp/it's a file.txt\"\n" +
+				`map oq $vim '/tmp/it'\''s a file.txt'` + "\n" +
+				"map gd cd '/my docs'\n")
+
+			fileFlags.lfFileOpenPrefix = ""
+			fileFlags.editor = ""
+			lines, _ = renderLfMappings(bookmarks[:1], fileFlags)
+			g.Assert(lines).Equal("map bh select /etc/hosts\n")
+			g.Assert(lfBookmarkKeys(bookmarks[0], fileFlags)).Equal([]string{"bh"})
+		})
+
+		g.It("creates lfrc and its folder when they do not exist", func() {
+			var missingFlags = flags
+			missingFlags.lfConfigFile = path.Join(flags.homePath, "xdg", "lf", "lfrc")
+			var err = generateLfMappings([]Bookmark{{typ: "dir", path: "/tmp", abbreviation: "t"}}, missingFlags)
+			g.Assert(err).IsNil()
+			var text, _, _ = readTextFromFile(missingFlags.lfConfigFile)
+			g.Assert(text).Equal(inManagedBlock("map gt cd /tmp\n"))
+		})
 	})
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestLfMarks(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("lf marks generation", func() {
+		var flags = Flags{homePath: "/home/me", lfMarksFile: "/home/me/.local/share/lf/marks"}
+		var read = func(filename string) string {
+			var text, _, _ = readTextFromFile(filename)
+			return text
+		}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			t.Setenv("XDG_STATE_HOME", "")
+		})
+
+		g.It("writes single character folders and leaves the rest out", func() {
+			var bookmarks = []Bookmark{
+				{typ: "dir", path: "work/", abbreviation: "w"},
+				{typ: "dir", path: "/tmp/", abbreviation: "tmp"},
+				{typ: "file", path: "/etc/hosts", abbreviation: "h"},
+				{typ: "dir", path: "/opt/", abbreviation: "é"},
+			}
+			g.Assert(generateLfMarks(bookmarks, flags)).IsNil()
+			g.Assert(read(flags.lfMarksFile)).Equal("w:/home/me/work\né:/opt\n")
+		})
+
+		g.It("keeps the marks set in lf and removes old bookmarks", func() {
+			afero.WriteFile(AppFs, flags.lfMarksFile, []byte("':/home/me\na:/srv\n"), 0644)
+			g.Assert(generateLfMarks([]Bookmark{
+				{typ: "dir", path: "/old", abbreviation: "o"},
+				{typ: "dir", path: "/moved", abbreviation: "m"},
+			}, flags)).IsNil()
+			g.Assert(read(flags.lfMarksFile)).Equal("':/home/me\na:/srv\nm:/moved\no:/old\n")
+
+			// m is set again in lf, so it is not ours anymore
+			afero.WriteFile(AppFs, flags.lfMarksFile, []byte("':/home/me\na:/srv\nm:/elsewhere\no:/old\n"), 0644)
+			g.Assert(generateLfMarks([]Bookmark{{typ: "dir", path: "/srv/new", abbreviation: "a"}}, flags)).IsNil()
+			g.Assert(read(flags.lfMarksFile)).Equal("':/home/me\na:/srv/new\nm:/elsewhere\n")
+			g.Assert(read(lfMarksStateFile(flags))).Equal("a:/srv/new\n")
+		})
+
+		g.It("remembers the marks of every profile on its own", func() {
+			var workFlags = flags
+			workFlags.profile = "work"
+			g.Assert(generateLfMarks([]Bookmark{{typ: "dir", path: "/a", abbreviation: "a"}}, flags)).IsNil()
+			g.Assert(generateLfMarks([]Bookmark{{typ: "dir", path: "/b", abbreviation: "b"}}, workFlags)).IsNil()
+			g.Assert(read(flags.lfMarksFile)).Equal("a:/a\nb:/b\n")
+			g.Assert(lfMarksStateFile(workFlags)).Equal("/home/me/.local/state/bookmarker/lf-marks-work")
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// like exec.LookPath, but through AppFs so it can be tested
+var findExecutable = func(name string) (string, bool) {
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if len(dir) == 0 {
+			continue
+		}
+		var candidate = path.Join(dir, name)
+		var info, err = AppFs.Stat(candidate)
+		if err == nil && !info.IsDir() && info.Mode().Perm()&0111 != 0 {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// the name the shell generator gives the bookmark
+var aliasName = func(bm Bookmark, flags Flags) string {
+	switch bm.typ {
+	case "dir":
+		return flags.shellAliasFolderPrefix + bm.abbreviation
+	case "file":
+		return flags.shellAliasFilePrefix + bm.abbreviation
+	default:
+		return bm.abbreviation
+	}
+}
+
+/*
+everything parseFile complains about, and the things that are allowed but probably a mistake:
+two bookmarks with the same alias, two bookmarks for the same path, and aliases that hide a command or a builtin
+missing paths are warnings unless --missing says otherwise
+*/
+var lintBookmarks = func(text string, flags Flags) ParseErrors {
+	var bookmarks, problems = parseLocatedBookmarks(text, flags)
+
+	var warn = func(at locatedBookmark, format string, args ...interface{}) {
+		problems = append(problems, ParseError{
+			File:         at.file,
+			Line:         at.line,
+			Column:       at.column,
+			Severity:     SeverityWarning,
+			Message:      fmt.Sprintf(format, args...),
+			IncludedFrom: at.includedFrom,
+		})
+	}
+	// the line alone when it is in the same file
+	var where = func(at locatedBookmark, first locatedBookmark) string {
+		if at.file == first.file {
+			return fmt.Sprintf("line %d", first.line)
+		}
+		return first.position()
+	}
+
+	// c .config/ and c notes.md are cdc and cfc, so what has to be unique is the alias
+	var aliases = map[string]locatedBookmark{}
+	var paths = map[string]locatedBookmark{}
+	for _, at := range bookmarks {
+		var bm = at.Bookmark
+		var name = aliasName(bm, flags)
+		if first, exists := aliases[name]; !exists {
+			aliases[name] = at
+		} else if first.abbreviation == bm.abbreviation {
+			warn(at, "abbreviation %s is already used on %s, this one replaces it", bm.abbreviation, where(at, first))
+		} else {
+			warn(at, "alias %s is already used by %s on %s, this one replaces it", name, first.abbreviation, where(at, first))
+		}
+
+		if bm.typ != "shell" {
+			var target = path.Clean(resolve(bm.path, flags))
+			if first, exists := paths[target]; exists {
+				warn(at, "%s points to %s like %s on %s", bm.abbreviation, target, first.abbreviation, where(at, first))
+			} else {
+				paths[target] = at
+			}
+		}
+
+		if contains(shellBuiltins, name) {
+			warn(at, "alias %s hides the shell builtin %s", name, name)
+		} else if executable, exists := findExecutable(name); exists {
+			warn(at, "alias %s hides the command %s", name, executable)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		var a, b = documentOrder(problems[i]), documentOrder(problems[j])
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return problems
+}
+
+// the lines of the include lines that lead to the problem, then its own line and column
+// so problems in an included file come where the include line is
+var documentOrder = func(problem ParseError) []int {
+	var order = []int{}
+	for i := len(problem.IncludedFrom) - 1; i >= 0; i-- {
+		var include = problem.IncludedFrom[i]
+		var line, _ = strconv.Atoi(include[strings.LastIndexByte(include, ':')+1:])
+		order = append(order, line)
+	}
+	return append(order, problem.Line, problem.Column)
+}
+
+// 0 when there is nothing to say, 1 for warnings and 2 for errors
+var lintExitCode = func(problems ParseErrors) int {
+	if problems.HasErrors() {
+		return 2
+	} else if len(problems) != 0 {
+		return 1
+	}
+	return 0
+}
+
+var newLintCommand = func(flags *Flags) *cobra.Command {
+	var asJSON bool
+	var lintCmd = &cobra.Command{
+		Use:     "lint",
+		Aliases: []string{"check"},
+		Short:   "Report problems in the bookmark file without changing anything, exits with 1 for warnings and 2 for errors",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// a missing path should be reported, not stop the check
+			if !cmd.Flags().Changed("missing") {
+				flags.missingPolicy = "warn"
+			}
+			var text, file, err = readTextFromFile(flags.bookmarkFile)
+			exitIf(err)
+			file.Close()
+
+			var problems = lintBookmarks(text, *flags)
+			if asJSON {
+				if problems == nil {
+					problems = ParseErrors{}
+				}
+				var output, jsonErr = json.MarshalIndent(problems, "", "  ")
+				exitIf(jsonErr)
+				fmt.Println(string(output))
+			} else if len(problems) == 0 {
+				fmt.Println("No problems found in", flags.bookmarkFile)
+			} else {
+				for _, problem := range problems {
+					fmt.Println(problem.Error())
+				}
+			}
+			os.Exit(lintExitCode(problems))
+		},
+	}
+	lintCmd.Flags().BoolVar(&asJSON, "json", false, "Print the problems as a JSON array")
+	return lintCmd
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestLint(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("bookmark file lint", func() {
+		var flags = Flags{
+			homePath:               "/home/me",
+			bookmarkFile:           "list",
+			missingPolicy:          "warn",
+			shellAliasFolderPrefix: "c",
+			shellAliasFilePrefix:   "cf",
+		}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/work", 0755)
+			AppFs.MkdirAll("/usr/bin", 0755)
+			afero.WriteFile(AppFs, "/usr/bin/ls", []byte{}, 0755)
+			afero.WriteFile(AppFs, "/usr/bin/cw", []byte{}, 0644)
+			t.Setenv("PATH", "/usr/bin")
+		})
+
+		g.It("finds nothing in a healthy file", func() {
+			var problems = lintBookmarks("# work\nw work/\n!up sudo apt update\n", flags)
+			g.Assert(len(problems)).Equal(0)
+			g.Assert(lintExitCode(problems)).Equal(0)
+		})
+
+		g.It("reports duplicates, shared paths and shadowed commands with line numbers", func() {
+			var text = "w work/\n" +
+				"work /home/me/work/\n" +
+				"  w /tmp/\n" +
+				"!ls exa -la\n" +
+				"!cd echo\n"
+			var problems = lintBookmarks(text, flags)
+			var messages = []string{}
+			for _, problem := range problems {
+				messages = append(messages, problem.Error())
+			}
+			g.Assert(messages).Equal([]string{
+				"list:2:1: warning: work points to /home/me/work like w on line 1",
+				"list:3:3: warning: abbreviation w is already used on line 1, this one replaces it",
+				"list:3:5: warning: filepath /tmp/ does not exist",
+				"list:4:2: warning: alias ls hides the command /usr/bin/ls",
+				"list:5:2: warning: alias cd hides the shell builtin cd",
+			})
+			g.Assert(lintExitCode(problems)).Equal(1)
+		})
+
+		g.It("only reports bookmarks that end up with the same alias", func() {
+			afero.WriteFile(AppFs, "/home/me/notes.md", []byte{}, 0644)
+			var problems = lintBookmarks("w work/\nw notes.md\nfw /tmp/\n", flags)
+			g.Assert(len(problems)).Equal(2)
+			g.Assert(problems[0].Error()).Equal("list:3:1: warning: alias cfw is already used by w on line 2, this one replaces it")
+			g.Assert(problems[1].Message).Equal("filepath /tmp/ does not exist")
+		})
+
+		g.It("exits with the worst severity", func() {
+			var errorFlags = flags
+			errorFlags.missingPolicy = "error"
+			var problems = lintBookmarks("w work/\nx gone/\n'y\n", errorFlags)
+			g.Assert(len(problems)).Equal(2)
+			g.Assert(problems[0].Line).Equal(2)
+			g.Assert(problems[1].Line).Equal(3)
+			g.Assert(lintExitCode(problems)).Equal(2)
+		})
+
+		g.It("prints json with the severity as text", func() {
+			var output, err = json.Marshal(lintBookmarks("x gone/\n", flags))
+			g.Assert(err).IsNil()
+			g.Assert(string(output)).Equal(`[{"file":"list","line":1,"column":3,"severity":"warning","message":"filepath gone/ does not exist"}]`)
+		})
+
+		g.It("says when a symlink is broken", func() {
+			AppFs = afero.NewOsFs()
+			var dir = t.TempDir()
+			os.Symlink(path.Join(dir, "gone"), path.Join(dir, "link"))
+			var problems = lintBookmarks("l "+path.Join(dir, "link")+"/\n", flags)
+			g.Assert(len(problems)).Equal(1)
+			g.Assert(problems[0].Message).Equal("filepath " + path.Join(dir, "link") + "/ is a broken symlink, " + path.Join(dir, "gone") + " does not exist")
+		})
+	})
+}
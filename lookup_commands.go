@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+)
+
+// the last one wins, like the generated aliases where a later alias replaces an earlier one
+// no types means any type
+var findBookmark = func(bms []Bookmark, abbreviation string, types ...string) (Bookmark, bool) {
+	var found Bookmark
+	var exists = false
+	for _, bm := range bms {
+		if bm.abbreviation == abbreviation && (len(types) == 0 || contains(types, bm.typ)) {
+			found = bm
+			exists = true
+		}
+	}
+	return found, exists
+}
+
+// starts a program attached to the terminal and waits for it
+var runInTerminal = func(name string, args []string, dir string) error {
+	var command = exec.Command(name, args...)
+	command.Dir = dir
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
+
+var userShell = func() string {
+	if shell, exists := os.LookupEnv("SHELL"); exists && len(shell) != 0 {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// the editor for files, the shell for folders, and the command itself for shell bookmarks
+var openBookmark = func(bm Bookmark, flags Flags) error {
+	switch bm.typ {
+	case "file":
+		var editor = flags.editor
+		if len(editor) == 0 {
+			editor = os.Getenv("EDITOR")
+		}
+		var tokens, lexErr = lexLine(editor)
+		if lexErr != nil {
+			return fmt.Errorf("cannot read editor command %s: %s", editor, lexErr.Error())
+		}
+		if len(tokens) == 0 {
+			return errors.New("$EDITOR variable does not exist")
+		}
+		var args = []string{}
+		for _, tok := range tokens[1:] {
+			args = append(args, tok.text)
+		}
+		return runInTerminal(tokens[0].text, append(args, resolve(bm.path, flags)), "")
+	case "dir":
+		return runInTerminal(userShell(), []string{}, resolve(bm.path, flags))
+	default:
+		return runInTerminal(userShell(), []string{"-c", bm.path}, "")
+	}
+}
+
+// lookups should keep working on a machine that misses some of the paths
+var skipMissingByDefault = func(cmd *cobra.Command, flags *Flags) {
+	if !cmd.Flags().Changed("missing") && !flags.disableValidation {
+		flags.missingPolicy = "skip"
+	}
+}
+
+var newLookupCommands = func(flags *Flags) []*cobra.Command {
+	var pathCmd = &cobra.Command{
+		Use:   "path <abbreviation>",
+		Short: "Print the absolute path of a folder or file bookmark",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			skipMissingByDefault(cmd, flags)
+			var bms, err = readBookmarks(*flags)
+			exitIf(err)
+			var bm, exists = findBookmark(bms, args[0], "dir", "file")
+			if !exists {
+				exitIf(fmt.Errorf("there is no folder or file bookmark called %s", args[0]))
+			}
+			fmt.Println(resolve(bm.path, *flags))
+		},
+	}
+
+	var openCmd = &cobra.Command{
+		Use:   "open <abbreviation>",
+		Short: "Open a bookmark: files in the editor, a shell in folders, and run commands",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			skipMissingByDefault(cmd, flags)
+			var bms, err = readBookmarks(*flags)
+			exitIf(err)
+			var bm, exists = findBookmark(bms, args[0])
+			if !exists {
+				exitIf(fmt.Errorf("there is no bookmark called %s", args[0]))
+			}
+			// pass the exit code of the program on, so scripts can check it
+			var runErr = openBookmark(bm, *flags)
+			var exitErr *exec.ExitError
+			if errors.As(runErr, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			exitIf(runErr)
+		},
+	}
+
+	// a program cannot change the folder of the shell that started it, the shell function from bm init does it
+	var cdCmd = &cobra.Command{
+		Use:   "cd <abbreviation>",
+		Short: "Go to a folder bookmark (needs the shell function from bm init)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			exitIf(errors.New("bm cd only works with the shell function from bm init, add eval \"$(bm init bash)\" (or zsh, fish) to your shell rc file, or use cd \"$(bm path " + args[0] + ")\""))
+		},
+	}
+
+	return []*cobra.Command{pathCmd, openCmd, cdCmd}
+}
+
+// turns bm cd <abbreviation> into cd "$(bm path <abbreviation>)" in the shell itself
+var renderShellWrapper = func(shell string, flags Flags) string {
+	if shell == "fish" {
+		return "function bm\n" +
+			"    if test \"$argv[1]\" = cd\n" +
+			"        set -l dir (command bm path --bookmark-file " + fishQuote(flags.bookmarkFile) + " --home-path " + fishQuote(flags.homePath) + " $argv[2..-1]); and cd $dir\n" +
+			"    else\n" +
+			"        command bm $argv\n" +
+			"    end\n" +
+			"end\n"
+	}
+	return "bm() {\n" +
+		"  if [ \"${1-}\" = cd ]; then\n" +
+		"    shift\n" +
+		"    __bm_dir=$(command bm path --bookmark-file " + shellQuote(flags.bookmarkFile) + " --home-path " + shellQuote(flags.homePath) + " \"$@\") && cd \"$__bm_dir\"\n" +
+		"    set -- \"$?\"\n" +
+		"    unset __bm_dir\n" +
+		"    return \"$1\"\n" +
+		"  fi\n" +
+		"  command bm \"$@\"\n" +
+		"}\n"
+}
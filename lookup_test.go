@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestLookup(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("bookmark lookup", func() {
+		var bookmarks = []Bookmark{
+			{typ: "dir", path: ".config/", abbreviation: "c"},
+			{typ: "shell", path: "ls -l", abbreviation: "l"},
+			{typ: "file", path: "/etc/hosts", abbreviation: "h"},
+			{typ: "dir", path: "/tmp/", abbreviation: "c"},
+		}
+		var flags = Flags{homePath: "/home/me", editor: "code --wait"}
+
+		g.It("finds the last bookmark with the abbreviation", func() {
+			var bm, exists = findBookmark(bookmarks, "c")
+			g.Assert(exists).IsTrue()
+			g.Assert(bm).Equal(bookmarks[3])
+
+			_, exists = findBookmark(bookmarks, "l", "dir", "file")
+			g.Assert(exists).IsFalse()
+			_, exists = findBookmark(bookmarks, "nope")
+			g.Assert(exists).IsFalse()
+		})
+
+		g.It("opens every type with the right program", func() {
+			var original = runInTerminal
+			defer func() { runInTerminal = original }()
+			var calls = [][]string{}
+			runInTerminal = func(name string, args []string, dir string) error {
+				calls = append(calls, append([]string{dir, name}, args...))
+				return nil
+			}
+			t.Setenv("SHELL", "/bin/zsh")
+
+			openBookmark(bookmarks[2], flags)
+			openBookmark(bookmarks[0], flags)
+			openBookmark(bookmarks[1], flags)
+			g.Assert(calls).Equal([][]string{
+				{"", "code", "--wait", "/etc/hosts"},
+				{"/home/me/.config", "/bin/zsh"},
+				{"", "/bin/zsh", "-c", "ls -l"},
+			})
+		})
+	})
+}
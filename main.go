@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"os"
-	"path"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -13,24 +12,51 @@ import (
 var AppFs = afero.NewOsFs()
 
 type Flags struct {
-	bookmarkFile string
-	// disableValidation bool
-	homePath       string
-	debug          bool
-	editor         string
-	shellAliasFile string
-  shellAliasFolderPrefix string
-  shellAliasFilePrefix string
-  lfMappingPrefix string
+	bookmarkFile           string
+	disableValidation      bool
+	missingPolicy          string
+	strict                 bool
+	homePath               string
+	debug                  bool
+	editor                 string
+	shellAliasFile         string
+	shellAliasFolderPrefix string
+	shellAliasFilePrefix   string
+	lfMappingPrefix        string
+	lfConfigFile           string
+	lfFileSelectPrefix     string
+	lfFileOpenPrefix       string
+	lfMarksFile            string
+	lfStrict               bool
+	fishFile               string
+	zshFile                string
+	zshStandalone          bool
+	shellFunctions         bool
+	bashCdableVars         bool
+	bashVariablePrefix     string
+	generators             []string
+	profile                string
+	skipGenerators         []string
+	dryRun                 bool
+	initCache              bool
+	// not a flag: bm init prints code that reads $EDITOR when it runs, if no editor is known now
+	editorAtRuntime bool
 }
 
 var parseCommand = func() {
 	var flags = Flags{}
+	var configFile string
+	// where every setting came from, for bm config show
+	var sources = map[string]string{}
 	var rootCmd = &cobra.Command{
 		Use:   "bm",
 		Short: "bookmarker -- or file shortcuts",
 		Long:  "Input a bookmark file and this program will add them to various applications. \n\nEach line in a the input file looks like this:\n\n[suffix] [path]\n\nFor example, if you have `v .vimrc`, then the program will create an alias `cfv` to your shell profile. When you type cfv, your editor will launch ~/.vimrc. You can change for what program it generates for by modifying the source code or providing flags.",
-		Run: func(cmd *cobra.Command, args []string) {
+		// every subcommand shares the flags and this setup
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// defaults < config file < $BM_... < command line
+			exitIf(applyConfig(cmd, sources))
+
 			// set log level - can be configured with command line arguments
 			log.SetFormatter(&log.TextFormatter{
 				ForceColors:            true,
@@ -38,51 +64,93 @@ var parseCommand = func() {
 				PadLevelText:           true,
 				DisableTimestamp:       true,
 			})
-			log.SetOutput(os.Stdout)
+			// warnings go to stderr, stdout is for what the command prints (a diff, a path, shell code)
+			log.SetOutput(os.Stderr)
 
 			// enable debug output
 			if flags.debug {
 				log.SetLevel(log.DebugLevel)
 			} else {
-				log.SetLevel(log.FatalLevel)
+				log.SetLevel(log.WarnLevel)
 			}
 
 			// check if home path makes sense
 			checkHomePath(flags)
 
-			// read and parse the input file, returns bookmarks
-			// the bookmarks then will be fed to the generators
-			var text, file, err = readTextFromFile(flags.bookmarkFile)
-			exitIf(err)
-			var bms, pathErr = parseFile(string(text), flags)
-			exitIf(pathErr)
-
-			defer file.Close()
-			// here are the generators
-			// a generator is just a function that receives bookmarks array
-
-			var shellAliasErr = generateShellAliases(bms, flags)
-			exitIf(shellAliasErr)
-			var lfMappingsErr = generateLfMappings(bms, flags)
-			exitIf(lfMappingsErr)
+			if flags.disableValidation {
+				flags.missingPolicy = "warn"
+			}
+			exitIf(checkMissingPolicy(flags))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			// show what would change instead of changing it
+			if flags.dryRun {
+				var changed, dryRunErr = dryRun(func() error { return generateAll(flags) }, os.Stdout)
+				exitIf(dryRunErr)
+				if changed {
+					os.Exit(1)
+				}
+				fmt.Println("Everything is up to date")
+				return
+			}
 
-			// end of generators
+			exitIf(generateAll(flags))
 
 			// so that the user knows the program succeeds
 			fmt.Println("Bookmarks has all been generated")
 		},
 	}
 	var homedir, _ = os.UserHomeDir()
+	var xdg = xdgBaseDirectories(homedir)
+
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", defaultPaths["config"](xdg), "The config file, its settings are used for the flags that are not given ($BM_... variables win over it)")
+	rootCmd.PersistentFlags().StringVarP(&flags.homePath, "home-path", "H", homedir, "The home path, uses $HOME if nothing is provided")
+	rootCmd.PersistentFlags().StringVarP(&flags.bookmarkFile, "bookmark-file", "b", defaultPaths["bookmark-file"](xdg), "Input book mark file")
+	rootCmd.PersistentFlags().StringVarP(&flags.editor, "editor", "e", "", "Editor for shell aliases (it will use $EDITOR if this flag is empty)")
+	rootCmd.PersistentFlags().StringVarP(&flags.shellAliasFile, "alias-file", "a", defaultPaths["alias-file"](xdg), "The filepath for the shell alias file. Remember to source it in your *rc or *profile files")
+	rootCmd.PersistentFlags().BoolVarP(&flags.debug, "debug", "v", false, "Enable debug output (warning: lots of unnecessary information)")
+	rootCmd.PersistentFlags().BoolVarP(&flags.disableValidation, "no-validate-path", "P", false, "Do not fail when the paths in the input file do not exist (same as --missing=warn)")
+	rootCmd.PersistentFlags().BoolVar(&flags.strict, "strict", false, "Treat a trailing / that does not match the path (a folder without it, a file with it) as an error")
+	rootCmd.PersistentFlags().StringVar(&flags.missingPolicy, "missing", "error", "What to do with paths that do not exist: error, warn (generate them anyway) or skip (leave them out)")
+	rootCmd.PersistentFlags().StringVarP(&flags.shellAliasFolderPrefix, "shell-alias-folder-prefix", "F", "cd", "The prefix for folder shortcuts in shell alias generator (default: cd)")
+	rootCmd.PersistentFlags().StringVarP(&flags.shellAliasFilePrefix, "shell-alias-file-prefix", "G", "cf", "The prefix for file shortcuts in shell alias generator (default: cf)")
+	rootCmd.PersistentFlags().StringVarP(&flags.lfMappingPrefix, "lf-mapping-prefix", "X", "g", "The prefix for shortcuts in lf generator (default: g)")
+	rootCmd.PersistentFlags().StringVar(&flags.lfConfigFile, "lf-config", defaultPaths["lf-config"](xdg), "The lfrc file for the lf generator, created when it does not exist")
+	rootCmd.PersistentFlags().StringVar(&flags.lfFileSelectPrefix, "lf-file-select-prefix", "b", "The prefix for lf shortcuts that select a file (and go to its folder), empty to leave them out")
+	rootCmd.PersistentFlags().StringVar(&flags.lfFileOpenPrefix, "lf-file-open-prefix", "", "The prefix for lf shortcuts that open a file in the editor, empty to leave them out")
+	rootCmd.PersistentFlags().BoolVar(&flags.lfStrict, "lf-strict", false, "Treat lf key conflicts between bookmarks, or with the default mappings of lf, as errors")
+	rootCmd.PersistentFlags().StringVar(&flags.lfMarksFile, "lf-marks-file", defaultPaths["lf-marks-file"](xdg), "The marks file of lf for the lf-marks generator")
+	rootCmd.PersistentFlags().BoolVar(&flags.shellFunctions, "shell-functions", false, "Generate shell functions instead of aliases for folders and files, so \"cdc alacritty\" goes to a folder under the bookmark (bash and zsh)")
+	rootCmd.PersistentFlags().BoolVar(&flags.bashCdableVars, "bash-cdable-vars", false, "Also export a variable for every folder in the alias file and turn on cdable_vars in bash, so \"cd bm_c\" works")
+	rootCmd.PersistentFlags().StringVar(&flags.bashVariablePrefix, "bash-variable-prefix", "bm_", "The prefix of the variables from --bash-cdable-vars")
+	rootCmd.PersistentFlags().StringVar(&flags.fishFile, "fish-file", defaultPaths["fish-file"](xdg), "The filepath for the fish generator. Files in conf.d are loaded by fish automatically")
+	rootCmd.PersistentFlags().StringVar(&flags.zshFile, "zsh-file", defaultPaths["zsh-file"](xdg), "The filepath for the zsh generator. Remember to source it in your .zshrc")
+	rootCmd.PersistentFlags().BoolVar(&flags.zshStandalone, "zsh-standalone", false, "Let the zsh generator own the whole zsh file instead of a section of it")
+	rootCmd.PersistentFlags().StringSliceVar(&flags.generators, "generators", []string{}, "Only run these generators (comma separated, see bm generators). Runs the default ones if empty")
+	rootCmd.PersistentFlags().StringVarP(&flags.profile, "profile", "p", "", "Name of the generated sections in the output files. Different profiles can share the same files without overwriting each other")
+	rootCmd.PersistentFlags().StringSliceVar(&flags.skipGenerators, "skip", []string{}, "Do not run these generators (comma separated)")
+	rootCmd.PersistentFlags().BoolVarP(&flags.dryRun, "dry-run", "n", false, "Do not write anything, print a diff of every file that would change instead. Exits with 1 if anything would change")
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "generators",
+		Short: "List the available generators",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, generator := range generators {
+				var description = generator.Description()
+				if !generator.DefaultEnabled() {
+					description += " (only runs when asked for with --generators)"
+				}
+				fmt.Printf("%-10s %s\n", generator.Name(), description)
+			}
+		},
+	})
 
-	rootCmd.Flags().StringVarP(&flags.homePath, "home-path", "H", homedir, "The home path, uses $HOME if nothing is provided")
-	rootCmd.Flags().StringVarP(&flags.bookmarkFile, "bookmark-file", "b", path.Join(homedir, ".config", "bookmarker", "list"), "Input book mark file")
-	rootCmd.Flags().StringVarP(&flags.editor, "editor", "e", "", "Editor for shell aliases (it will use $EDITOR if this flag is empty)")
-	rootCmd.Flags().StringVarP(&flags.shellAliasFile, "alias-file", "a", path.Join(homedir, ".config", "shell", "aliasrc"), "The filepath for the shell alias file. Remember to source it in your *rc or *profile files")
-	rootCmd.Flags().BoolVarP(&flags.debug, "debug", "v", false, "Enable debug output (warning: lots of unnecessary information)")
-	// rootCmd.Flags().BoolVarP(&flags.disableValidation, "no-validate-path", "P", false, "Do not check if the paths in the input file exist")
-  rootCmd.Flags().StringVarP(&flags.shellAliasFolderPrefix, "shell-alias-folder-prefix", "F", "cd", "The prefix for folder shortcuts in shell alias generator (default: cd)")
-  rootCmd.Flags().StringVarP(&flags.shellAliasFilePrefix, "shell-alias-file-prefix", "G", "cf", "The prefix for file shortcuts in shell alias generator (default: cf)")
-  rootCmd.Flags().StringVarP(&flags.lfMappingPrefix, "lf-mapping-prefix", "X", "g", "The prefix for shortcuts in lf generator (default: g)")
+	rootCmd.AddCommand(newInitCommand(&flags))
+	rootCmd.AddCommand(newLookupCommands(&flags)...)
+	rootCmd.AddCommand(newEditCommands(&flags)...)
+	rootCmd.AddCommand(newLintCommand(&flags))
+	rootCmd.AddCommand(newConfigCommand(sources))
 
 	// parse the command and run the callback
 	var parseErr = rootCmd.Execute()
@@ -98,4 +166,3 @@ type Bookmark struct {
 	path         string
 	abbreviation string
 }
-
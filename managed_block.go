@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+const managedBlockStart = "### Automatically generated by BOOKMARKER"
+const managedBlockEnd = "### End of BOOKMARKER generation"
+
+// the comments around the generated section of a file
+// every profile gets its own pair so they can share one file
+var managedBlockMarkers = func(profile string) (string, string) {
+	if len(profile) == 0 {
+		return managedBlockStart + " ###", managedBlockEnd
+	}
+	return managedBlockStart + " (" + profile + ") ###", managedBlockEnd + " (" + profile + ")"
+}
+
+// removes the old generated section of the profile from text and adds the new one at the end
+// anything outside of the section is kept as it is
+var replaceManagedBlock = func(text string, content string, profile string) string {
+	var start, end = managedBlockMarkers(profile)
+	var lines = strings.Split(text, "\n")
+	// the text ends with a new line, which is not another line
+	if len(lines) != 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var keptLines = ""
+	var insideGenerationSection = false
+
+	// finds the lines which are enclosed by the two comments
+	// and not include them
+	for _, line := range lines {
+		// omit the lines and check if we are out of it
+		if insideGenerationSection {
+			if line == end {
+				insideGenerationSection = false
+			}
+			continue
+		}
+
+		// trigger inside generation once the ### line is reached
+		if line == start {
+			insideGenerationSection = true
+		} else {
+			keptLines += line + "\n"
+		}
+	}
+
+	return keptLines + start + "\n\n" + content + "\n" + end + "\n"
+}
+
+// puts content into the generated section of a file, the file is created if it does not exist
+var updateManagedBlock = func(filepath string, content string, flags Flags) error {
+	var text, file, err = readTextFromFile(filepath)
+	if file != nil {
+		file.Close()
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return writeFileAtomic(filepath, []byte(replaceManagedBlock(text, content, flags.profile)))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestManagedBlock(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("managed blocks", func() {
+		var homeDir, _ = os.UserHomeDir()
+		var aliasFile = path.Join(homeDir, ".config", "shell", "aliasrc")
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll(path.Join(homeDir, ".config", "shell"), os.ModeDir)
+		})
+
+		g.It("keeps everything outside of the block", func() {
+			var text = replaceManagedBlock("alias ll='ls -l'\n"+START_GENERATION_STRING+"\n\nold\n\n"+END_GENERATION_STRING+"\nalias la='ls -a'\n", "new\n", "")
+			g.Assert(text).Equal("alias ll='ls -l'\nalias la='ls -a'\n" + START_GENERATION_STRING + "\n\nnew\n\n" + END_GENERATION_STRING + "\n")
+		})
+
+		g.It("creates the file if it does not exist", func() {
+			var err = updateManagedBlock(aliasFile, "alias a='b'\n", Flags{})
+			g.Assert(err).IsNil()
+			var text, _, _ = readTextFromFile(aliasFile)
+			g.Assert(text).Equal(START_GENERATION_STRING + "\n\nalias a='b'\n\n" + END_GENERATION_STRING + "\n")
+		})
+
+		g.It("lets every profile own its own block", func() {
+			afero.WriteFile(AppFs, aliasFile, []byte("alias ll='ls -l'\n"), 0644)
+			updateManagedBlock(aliasFile, "home\n", Flags{profile: "home"})
+			updateManagedBlock(aliasFile, "work\n", Flags{profile: "work"})
+			updateManagedBlock(aliasFile, "home again\n", Flags{profile: "home"})
+
+			var text, _, _ = readTextFromFile(aliasFile)
+			g.Assert(text).Equal("alias ll='ls -l'\n" +
+				"### Automatically generated by BOOKMARKER (work) ###\n\nwork\n\n### End of BOOKMARKER generation (work)\n" +
+				"### Automatically generated by BOOKMARKER (home) ###\n\nhome again\n\n### End of BOOKMARKER generation (home)\n")
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// so json output says "warning" or "error" instead of a number
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// a problem found in the bookmark file
+// line and column both start from 1, like what editors and compilers use
+type ParseError struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// the include lines that lead to the file, the innermost first
+	IncludedFrom []string `json:"included_from,omitempty"`
+}
+
+// compiler style, so editors can jump to the line: list:12:3: filepath does not exist
+// with includes: team:4:3: filepath does not exist (included from list:2)
+func (e ParseError) Error() string {
+	var message = e.Message
+	if e.Severity == SeverityWarning {
+		message = "warning: " + message
+	}
+	if len(e.IncludedFrom) != 0 {
+		message += " (included from " + strings.Join(e.IncludedFrom, ", ") + ")"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, message)
+}
+
+// every problem in a file, in the order they were found
+type ParseErrors []ParseError
+
+func (errs ParseErrors) Error() string {
+	var messages = make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// warnings alone should not stop the program
+func (errs ParseErrors) HasErrors() bool {
+	for _, err := range errs {
+		if err.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -3,81 +3,360 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path"
+	"regexp"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
 /*
 must be of format:
 [!][abbreviation] [absolute path that can be resolved by your shell]
+the line is split into tokens by lexLine, so paths with spaces can be 'quoted' or escaped with \
 if the second one ends with a trailing /, then it will be a folder bookmark instead of a file bookmark
+when the path exists, it must agree with the trailing /, or there will be a warning (an error with --strict)
 c ~/.config/ (will be a folder bookmark)
 ac ~/.config/alacritty/alacritty.yml (will be a file bookmark)
 
 if the line starts with a !, that means it is just a normal shell alias
+if the line starts with a ?, the bookmark is optional and skipped quietly when the path does not exist
+
+a line like $svc = ~/work/services defines a variable for the lines after it
+paths can use $svc, ${svc}, environment variables and ${VAR:-default}, except inside 'single quotes'
+a path that starts with ~ or ~user starts in that home folder
+
+include <path> reads the bookmarks of another file at that line, include? <path> too, but only if the file exists
+a relative path is next to the file with the include, and the included file shares the variables
+the commands of ! lines are left alone, the shell expands them when they run
+
+what happens to other paths that do not exist depends on --missing:
+error (default) stops the program, warn generates the bookmark anyway and skip leaves it out
 
 the function also ignores lines with only comment, blank lines and any thing that comes after the format
+
+a bad line does not stop the parsing, every problem is collected and returned together
 */
-var parseFile = func(text string, flags Flags) ([]Bookmark, error) {
+var parseFile = func(text string, flags Flags) ([]Bookmark, ParseErrors) {
+	var located, errs = parseLocatedBookmarks(text, flags)
+	var bookmarks = make([]Bookmark, 0, len(located))
+	for _, bm := range located {
+		bookmarks = append(bookmarks, bm.Bookmark)
+	}
+	return bookmarks, errs
+}
+
+// a bookmark and where its abbreviation is, counting from 1
+type locatedBookmark struct {
+	Bookmark
+	file         string
+	line         int
+	column       int
+	includedFrom []string
+}
+
+// where the bookmark is, like list:3
+func (b locatedBookmark) position() string {
+	return fmt.Sprintf("%s:%d", b.file, b.line)
+}
+
+// what the parser keeps while it goes through included files
+type includeState struct {
+	variables map[string]string
+	// the files being parsed, the outermost first, to find include cycles
+	stack []string
+	// the include lines that led to the file being parsed, the outermost first
+	includes []string
+	// the included files and environment variables, for everything that is parsed
+	inputs bookmarkInputs
+}
+
+// what the bookmarks come from besides the bookmark file, so bm init knows when its cache is old
+type bookmarkInputs struct {
+	// every file that was included
+	Includes []string `json:"includes"`
+	// the files of include? that do not exist
+	Missing []string `json:"missing"`
+	// the environment variables the file uses, whether they are set or not
+	Environment []string `json:"environment"`
+}
+
+// the include lines, the innermost first, like compilers print them
+func (state *includeState) chain() []string {
+	if len(state.includes) == 0 {
+		return nil
+	}
+	var chain = make([]string, 0, len(state.includes))
+	for i := len(state.includes) - 1; i >= 0; i-- {
+		chain = append(chain, state.includes[i])
+	}
+	return chain
+}
+
+// include and include? have to be written as they are, so 'include' is still an abbreviation
+var isIncludeLine = func(line string, tokens []token) bool {
+	if len(tokens) < 2 {
+		return false
+	}
+	var written = line[tokens[0].start:tokens[0].end]
+	return written == "include" || written == "include?"
+}
+
+// $name = value
+var variableDefinition = regexp.MustCompile(`^\s*\$([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+var lookupUserHome = func(name string) (string, error) {
+	var found, err = user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("cannot find the home folder of %s: %s", name, err.Error())
+	}
+	return found.HomeDir, nil
+}
+
+// ~/x is x in the home path, ~user/x is x in the home folder of user
+var expandTilde = func(filepath string, flags Flags) (string, error) {
+	var name, rest = filepath[1:], ""
+	if slash := strings.IndexByte(filepath, '/'); slash != -1 {
+		name, rest = filepath[1:slash], filepath[slash:]
+	}
+	var home string
+	var err error
+	if len(name) == 0 {
+		home, err = getHomePath(flags)
+	} else {
+		home, err = lookupUserHome(name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return home + rest, nil
+}
+
+// the path as it is written, with the variables and ~ replaced
+var expandWrittenPath = func(written string, lookup variableLookup, flags Flags) (string, *lexError) {
+	var tokens, lexErr = lexLineExpanding(written, lookup)
+	if lexErr != nil {
+		return "", lexErr
+	}
+	var filepath = tokens[0].text
+	if strings.HasPrefix(written, "~") {
+		var err error
+		if filepath, err = expandTilde(filepath, flags); err != nil {
+			return "", &lexError{column: 0, message: err.Error()}
+		}
+	}
+	return filepath, nil
+}
+
+// parseFile, but every bookmark also knows where it is
+var parseLocatedBookmarks = func(text string, flags Flags) ([]locatedBookmark, ParseErrors) {
+	var bookmarks, errs, _ = parseBookmarkFiles(text, flags)
+	return bookmarks, errs
+}
+
+// parseLocatedBookmarks, and what else the bookmarks were made from
+var parseBookmarkFiles = func(text string, flags Flags) ([]locatedBookmark, ParseErrors, bookmarkInputs) {
+	var state = &includeState{variables: map[string]string{}, stack: []string{path.Clean(flags.bookmarkFile)}}
+	var bookmarks, errs = parseIncluding(text, flags, state)
+	return bookmarks, errs, state.inputs
+}
+
+// flags.bookmarkFile is the file the text comes from
+func parseIncluding(text string, flags Flags, state *includeState) ([]locatedBookmark, ParseErrors) {
 	var lines = strings.Split(text, "\n")
 	var bookmark Bookmark
 	// generate 10 lines first
-	var bookmarks = make([]Bookmark, 0, 10)
+	var bookmarks = make([]locatedBookmark, 0, 10)
+	var errs ParseErrors
+	// the variables of the file hide the environment variables with the same name
+	var variables = state.variables
+	var lookup = func(name string) (string, bool) {
+		if value, exists := variables[name]; exists {
+			return value, true
+		}
+		if !contains(state.inputs.Environment, name) {
+			state.inputs.Environment = append(state.inputs.Environment, name)
+		}
+		return os.LookupEnv(name)
+	}
 	for index, line := range lines {
-		line = strings.Trim(line, " \t\n")
+		// keep going after a problem so every problem in the file is reported at once
+		var diagnose = func(severity Severity, column int, format string, args ...interface{}) {
+			errs = append(errs, ParseError{
+				File:         flags.bookmarkFile,
+				Line:         index + 1,
+				Column:       column + 1,
+				Severity:     severity,
+				Message:      fmt.Sprintf(format, args...),
+				IncludedFrom: state.chain(),
+			})
+		}
+		var report = func(column int, format string, args ...interface{}) {
+			diagnose(SeverityError, column, format, args...)
+		}
 		log.Debugln("parsing line", index, ":", line)
+
+		if match := variableDefinition.FindStringSubmatchIndex(line); match != nil {
+			var name, valueStart = line[match[2]:match[3]], match[1]
+			var tokens, lexErr = lexLineExpanding(line[valueStart:], lookup)
+			if lexErr != nil {
+				report(valueStart+lexErr.column, "%s", lexErr.message)
+				continue
+			}
+			if len(tokens) == 0 {
+				report(valueStart, "missing value for $%s", name)
+				continue
+			}
+			if len(tokens) > 1 {
+				report(valueStart+tokens[1].start, "the value of $%s is more than one word, quote it if it has spaces", name)
+				continue
+			}
+			var value = tokens[0].text
+			if strings.HasPrefix(line[valueStart+tokens[0].start:], "~") {
+				var err error
+				if value, err = expandTilde(value, flags); err != nil {
+					report(valueStart+tokens[0].start, "%s", err.Error())
+					continue
+				}
+			}
+			log.Debugln("variable", name, "is", value)
+			variables[name] = value
+			continue
+		}
+
+		var tokens, lexErr = lexLine(line)
+		if lexErr != nil {
+			report(lexErr.column, lexErr.message)
+			continue
+		}
 		// leave early if it is just a comment or blank lines
-		if strings.HasPrefix(line, "#") || len(line) == 0 {
+		if len(tokens) == 0 {
 			continue
-		} else if strings.HasPrefix(line, "!") {
-			var firstSpace = strings.Index(line, " ")
-			var asRune = []rune(line)
-			if firstSpace == -1 {
-				return []Bookmark{}, fmt.Errorf("line %s is missing aliased command", line)
+		} else if isIncludeLine(line, tokens) {
+			var optional = strings.HasSuffix(line[tokens[0].start:tokens[0].end], "?")
+			var filepath, expandErr = expandWrittenPath(line[tokens[1].start:tokens[1].end], lookup, flags)
+			if expandErr != nil {
+				report(tokens[1].start+expandErr.column, "%s", expandErr.message)
+				continue
+			}
+			if !strings.HasPrefix(filepath, "/") {
+				filepath = path.Join(path.Dir(flags.bookmarkFile), filepath)
+			}
+			filepath = path.Clean(filepath)
+			if contains(state.stack, filepath) {
+				report(tokens[1].start, "include cycle: %s -> %s", strings.Join(state.stack, " -> "), filepath)
+				continue
+			}
+			var included, file, err = readTextFromFile(filepath)
+			if file != nil {
+				file.Close()
+			}
+			if optional && errors.Is(err, fs.ErrNotExist) {
+				log.Debugln("skipping line", index, "because", filepath, "does not exist")
+				state.inputs.Missing = append(state.inputs.Missing, filepath)
+				continue
+			} else if err != nil {
+				report(tokens[1].start, "cannot include %s: %s", filepath, err.Error())
+				continue
 			}
-			var firstPart = string(asRune[1:firstSpace])
-			var secondPart = string(asRune[firstSpace+1:])
 
-			// valid input
-			if len(firstPart) == 0 {
-				return []Bookmark{}, fmt.Errorf("line %s is missing abbreviation", line)
+			var includedFlags = flags
+			includedFlags.bookmarkFile = filepath
+			state.inputs.Includes = append(state.inputs.Includes, filepath)
+			state.stack = append(state.stack, filepath)
+			state.includes = append(state.includes, fmt.Sprintf("%s:%d", flags.bookmarkFile, index+1))
+			var includedBookmarks, includedErrs = parseIncluding(included, includedFlags, state)
+			state.stack = state.stack[:len(state.stack)-1]
+			state.includes = state.includes[:len(state.includes)-1]
+			bookmarks = append(bookmarks, includedBookmarks...)
+			errs = append(errs, includedErrs...)
+			continue
+		} else if strings.HasPrefix(line[tokens[0].start:], "!") {
+			if len(tokens) < 2 {
+				report(tokens[0].end, "missing aliased command")
+				continue
 			}
-			if len(secondPart) == 0 {
-				return []Bookmark{}, fmt.Errorf("line %s is missing aliased command", line)
+			var abbreviation = strings.TrimPrefix(tokens[0].text, "!")
+			if len(abbreviation) == 0 {
+				report(tokens[0].start+1, "missing abbreviation")
+				continue
 			}
 
-			// if everything passes, generate a bookmark
+			// the command is given to the shell later, so keep it exactly like how it is written
+			// (without the comment after it)
 			bookmark = Bookmark{
 				typ:          "shell",
-				abbreviation: firstPart,
-				path:         secondPart,
+				abbreviation: abbreviation,
+				path:         line[tokens[1].start:tokens[len(tokens)-1].end],
 			}
 		} else {
-			var tokens = strings.Split(line, " ")
 			if len(tokens) < 2 {
-				return nil, fmt.Errorf("not enough arguments for line %s", line)
+				report(tokens[0].end, "not enough arguments, expected [abbreviation] [path]")
+				continue
 			}
 			// discard anything after the second token
-			var firstTwoTokens = tokens[:2]
-			var abbreviation, filepath = strings.Trim(firstTwoTokens[0], " \t"), firstTwoTokens[1]
+			var abbreviation = tokens[0].text
+			// ?abbreviation means it is fine if the path does not exist on this machine
+			var optional = strings.HasPrefix(line[tokens[0].start:], "?")
+			if optional {
+				abbreviation = strings.TrimPrefix(abbreviation, "?")
+			}
 
 			// check if abbreviation makes sense
 			if len(abbreviation) == 0 {
-				return nil, errors.New("abbreviation is empty")
+				report(tokens[0].start, "abbreviation is empty")
+				continue
 			}
 
-			// check filpath
-			var info, err = obtainPathInfo(filepath, flags)
-			if err != nil {
-				return nil, errors.New("filepath " + filepath + " does not exist")
+			// the path once more, this time with the variables replaced
+			var filepath, expandErr = expandWrittenPath(line[tokens[1].start:tokens[1].end], lookup, flags)
+			if expandErr != nil {
+				report(tokens[1].start+expandErr.column, "%s", expandErr.message)
+				continue
 			}
+
+			// check filpath
 			// go does not have ternaries. However, since more types may be available, this construct is okay
 			var typ string
-			if info.IsDir() {
-				typ = "dir"
+			var info, err = obtainPathInfo(filepath, flags)
+			if err == nil {
+				if info.IsDir() {
+					typ = "dir"
+				} else {
+					typ = "file"
+				}
+				// what is on the disk wins, but the user should know the line says something else
+				if declared := declaredType(filepath); declared != typ {
+					var severity = SeverityWarning
+					if flags.strict {
+						severity = SeverityError
+					}
+					if typ == "dir" {
+						diagnose(severity, tokens[1].start, "filepath %s is a folder but is bookmarked as a file, add a trailing / to bookmark it as a folder", filepath)
+					} else {
+						diagnose(severity, tokens[1].start, "filepath %s is a file but is bookmarked as a folder, remove the trailing / to bookmark it as a file", filepath)
+					}
+					if flags.strict {
+						continue
+					}
+				}
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				report(tokens[1].start, "cannot read filepath %s: %s", filepath, err.Error())
+				continue
+			} else if optional || flags.missingPolicy == "skip" {
+				log.Debugln("skipping line", index, "because", filepath, "does not exist")
+				continue
+			} else if flags.missingPolicy == "warn" {
+				diagnose(SeverityWarning, tokens[1].start, "%s", describeMissing(filepath, flags))
+				// nothing to stat, so trust the trailing slash
+				typ = declaredType(filepath)
 			} else {
-				typ = "file"
+				report(tokens[1].start, "%s", describeMissing(filepath, flags))
+				continue
 			}
 			// create a bookmark by the information above and append it to bookmarks
 			bookmark = Bookmark{
@@ -86,8 +365,64 @@ var parseFile = func(text string, flags Flags) ([]Bookmark, error) {
 				abbreviation: abbreviation,
 			}
 		}
-		bookmarks = append(bookmarks, bookmark)
+		// the column of the abbreviation, after the ! or ?
+		var column = tokens[0].start + 1
+		if bookmark.abbreviation != tokens[0].text {
+			column++
+		}
+		bookmarks = append(bookmarks, locatedBookmark{bookmark, flags.bookmarkFile, index + 1, column, state.chain()})
 		log.Debugln("bookmark", index, ":", bookmark)
 	}
-	return bookmarks, nil
+	return bookmarks, errs
+}
+
+// a symlink whose target is gone looks like a missing path to stat, so say what is really wrong
+var describeMissing = func(filepath string, flags Flags) string {
+	var lstater, canLstat = AppFs.(afero.Lstater)
+	var reader, canReadlink = AppFs.(afero.LinkReader)
+	if canLstat && canReadlink {
+		// without the trailing /, or lstat follows the link
+		var link = path.Clean(resolve(filepath, flags))
+		var info, _, err = lstater.LstatIfPossible(link)
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if target, readErr := reader.ReadlinkIfPossible(link); readErr == nil {
+				return fmt.Sprintf("filepath %s is a broken symlink, %s does not exist", filepath, target)
+			}
+		}
+	}
+	return fmt.Sprintf("filepath %s does not exist", filepath)
+}
+
+// the kind of bookmark the user asked for: a trailing / means a folder
+var declaredType = func(filepath string) string {
+	if strings.HasSuffix(filepath, "/") {
+		return "dir"
+	}
+	return "file"
+}
+
+// reads and parses the bookmark file given by the flags
+// warnings are printed, errors are returned together as ParseErrors
+var readBookmarks = func(flags Flags) ([]Bookmark, error) {
+	var bms, _, err = readBookmarkFiles(flags)
+	return bms, err
+}
+
+// readBookmarks, and what else the bookmarks were made from
+var readBookmarkFiles = func(flags Flags) ([]Bookmark, bookmarkInputs, error) {
+	var text, file, err = readTextFromFile(flags.bookmarkFile)
+	if err != nil {
+		return nil, bookmarkInputs{}, err
+	}
+	file.Close()
+	var located, parseErrs, inputs = parseBookmarkFiles(text, flags)
+	if parseErrs.HasErrors() {
+		return nil, bookmarkInputs{}, parseErrs
+	}
+	printParseWarnings(parseErrs)
+	var bms = make([]Bookmark, 0, len(located))
+	for _, bm := range located {
+		bms = append(bms, bm.Bookmark)
+	}
+	return bms, inputs, nil
 }
@@ -29,6 +29,7 @@ func TestParse(t *testing.T) {
 			AppFs.Mkdir(addHome(".config"), os.ModeDir)
 			AppFs.Mkdir(addHome(".config/whatever"), os.ModeDir)
 			AppFs.Create(addHome(".config/whatever/conf"))
+			AppFs.Mkdir(addHome("My Documents"), os.ModeDir)
 			log.SetLevel(log.FatalLevel)
 		})
 
@@ -39,7 +40,8 @@ func TestParse(t *testing.T) {
 
 		var homePath, _ = os.UserHomeDir()
 		var flags = Flags{
-			homePath: homePath,
+			homePath:     homePath,
+			bookmarkFile: "list",
 		}
 
 		g.It("parse valid strings that have no comments", func() {
@@ -95,19 +97,19 @@ func TestParse(t *testing.T) {
 		g.It("can return abbreviation error and filepath error", func() {
 			var res = []struct {
 				in   string
-				want error
+				want ParseErrors
 			}{
 				{
 					in:   " /varr",
-					want: errors.New("not enough arguments for line /varr"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 7, Severity: SeverityError, Message: "not enough arguments, expected [abbreviation] [path]"}},
 				},
 				{
 					in:   "v /varr",
-					want: errors.New("filepath /varr does not exist"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 3, Severity: SeverityError, Message: "filepath /varr does not exist"}},
 				},
 				{
 					in:   "cj .config/jj",
-					want: errors.New("filepath .config/jj does not exist"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 4, Severity: SeverityError, Message: "filepath .config/jj does not exist"}},
 				},
 			}
 
@@ -144,19 +146,19 @@ func TestParse(t *testing.T) {
 		g.It("invalid lines that start with ! throws error", func() {
 			var res = []struct {
 				in   string
-				want error
+				want ParseErrors
 			}{
 				{
 					in:   "!",
-					want: errors.New("line ! is missing aliased command"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 2, Severity: SeverityError, Message: "missing aliased command"}},
 				},
 				{
 					in:   "!k",
-					want: errors.New("line !k is missing aliased command"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 3, Severity: SeverityError, Message: "missing aliased command"}},
 				},
 				{
 					in:   "! echo 'hi'",
-					want: errors.New("line ! echo 'hi' is missing abbreviation"),
+					want: ParseErrors{{File: "list", Line: 1, Column: 2, Severity: SeverityError, Message: "missing abbreviation"}},
 				},
 			}
 
@@ -165,6 +167,206 @@ func TestParse(t *testing.T) {
 				g.Assert(err).Equal(pair.want)
 			}
 		})
+
+		g.It("parse quoted paths, tabs and repeated spaces", func() {
+			var res = []struct {
+				in   string
+				want []Bookmark
+			}{
+				{
+					in:   "c\t.config/",
+					want: []Bookmark{neededBookmarks[0]},
+				},
+				{
+					in:   "  cw    .config/whatever/conf\t\t",
+					want: []Bookmark{neededBookmarks[1]},
+				},
+				{
+					in: "d 'My Documents/' # quoted\nd2 \"My Documents/\"\nd3 My\\ Documents/",
+					want: []Bookmark{
+						{typ: "dir", path: "My Documents/", abbreviation: "d"},
+						{typ: "dir", path: "My Documents/", abbreviation: "d2"},
+						{typ: "dir", path: "My Documents/", abbreviation: "d3"},
+					},
+				},
+				{
+					in:   "!e  echo  \"a # b\"   # comment",
+					want: []Bookmark{{typ: "shell", path: "echo  \"a # b\"", abbreviation: "e"}},
+				},
+			}
+
+			for _, pair := range res {
+				var out, errs = parseFile(pair.in, flags)
+				g.Assert(errs).IsNil()
+				g.Assert(out).Equal(pair.want)
+			}
+		})
+
+		g.It("reports quotes that are never closed", func() {
+			var _, errs = parseFile("d 'My Documents", flags)
+			g.Assert(errs).Equal(ParseErrors{{File: "list", Line: 1, Column: 3, Severity: SeverityError, Message: "single quote is never closed"}})
+		})
+
+		g.It("follows --missing for paths that do not exist", func() {
+			var in = "c .config/\nw work/\nn notes.md"
+			var missingFlags = flags
+
+			missingFlags.missingPolicy = "warn"
+			var out, errs = parseFile(in, missingFlags)
+			g.Assert(out).Equal([]Bookmark{
+				neededBookmarks[0],
+				{typ: "dir", path: "work/", abbreviation: "w"},
+				{typ: "file", path: "notes.md", abbreviation: "n"},
+			})
+			g.Assert(errs).Equal(ParseErrors{
+				{File: "list", Line: 2, Column: 3, Severity: SeverityWarning, Message: "filepath work/ does not exist"},
+				{File: "list", Line: 3, Column: 3, Severity: SeverityWarning, Message: "filepath notes.md does not exist"},
+			})
+			g.Assert(errs.HasErrors()).IsFalse()
+
+			missingFlags.missingPolicy = "skip"
+			out, errs = parseFile(in, missingFlags)
+			g.Assert(out).Equal([]Bookmark{neededBookmarks[0]})
+			g.Assert(errs).IsNil()
+
+			missingFlags.missingPolicy = "error"
+			_, errs = parseFile(in, missingFlags)
+			g.Assert(len(errs)).Equal(2)
+			g.Assert(errs.HasErrors()).IsTrue()
+		})
+
+		g.It("skips optional bookmarks quietly", func() {
+			var out, errs = parseFile("?c .config/\n?w work/", flags)
+			g.Assert(out).Equal([]Bookmark{neededBookmarks[0]})
+			g.Assert(errs).IsNil()
+		})
+
+		g.It("checks the trailing / against the filesystem", func() {
+			var in = "c .config\ncw .config/whatever/conf/"
+			var out, errs = parseFile(in, flags)
+			g.Assert(out).Equal([]Bookmark{
+				{typ: "dir", path: ".config", abbreviation: "c"},
+				{typ: "file", path: ".config/whatever/conf/", abbreviation: "cw"},
+			})
+			g.Assert(errs).Equal(ParseErrors{
+				{File: "list", Line: 1, Column: 3, Severity: SeverityWarning, Message: "filepath .config is a folder but is bookmarked as a file, add a trailing / to bookmark it as a folder"},
+				{File: "list", Line: 2, Column: 4, Severity: SeverityWarning, Message: "filepath .config/whatever/conf/ is a file but is bookmarked as a folder, remove the trailing / to bookmark it as a file"},
+			})
+
+			var strictFlags = flags
+			strictFlags.strict = true
+			out, errs = parseFile(in, strictFlags)
+			g.Assert(out).Equal([]Bookmark{})
+			g.Assert(len(errs)).Equal(2)
+			g.Assert(errs.HasErrors()).IsTrue()
+		})
+
+		g.It("finds absolute files written with a trailing /", func() {
+			// a real filesystem, stat of file/ fails there
+			AppFs = afero.NewOsFs()
+			defer func() {
+				AppFs = afero.NewMemMapFs()
+				AppFs.MkdirAll(addHome(".config/whatever"), 0755)
+				AppFs.Create(addHome(".config/whatever/conf"))
+				AppFs.Mkdir(addHome("My Documents"), os.ModeDir)
+			}()
+			var dir = t.TempDir()
+			afero.WriteFile(AppFs, path.Join(dir, "file"), []byte{}, 0644)
+			var out, errs = parseFile("x "+dir+"/file/", flags)
+			g.Assert(out).Equal([]Bookmark{{typ: "file", path: dir + "/file/", abbreviation: "x"}})
+			g.Assert(errs).Equal(ParseErrors{
+				{File: "list", Line: 1, Column: 3, Severity: SeverityWarning, Message: "filepath " + dir + "/file/ is a file but is bookmarked as a folder, remove the trailing / to bookmark it as a file"},
+			})
+		})
+
+		g.It("keeps going and reports every bad line", func() {
+			var out, errs = parseFile("c .config/\nv /varr\n!\n  cj .config/jj\ncw .config/whatever/conf", flags)
+			g.Assert(out).Equal(neededBookmarks)
+			g.Assert(errs).Equal(ParseErrors{
+				{File: "list", Line: 2, Column: 3, Severity: SeverityError, Message: "filepath /varr does not exist"},
+				{File: "list", Line: 3, Column: 2, Severity: SeverityError, Message: "missing aliased command"},
+				{File: "list", Line: 4, Column: 6, Severity: SeverityError, Message: "filepath .config/jj does not exist"},
+			})
+			g.Assert(errs.HasErrors()).IsTrue()
+			g.Assert(errs[0].Error()).Equal("list:2:3: filepath /varr does not exist")
+		})
+
+		g.It("expands variables and ~ in paths", func() {
+			t.Setenv("BM_TEST_CONFIG", ".config")
+			var original = lookupUserHome
+			defer func() { lookupUserHome = original }()
+			lookupUserHome = func(name string) (string, error) {
+				if name == "me" {
+					return homePath, nil
+				}
+				return "", errors.New("unknown user " + name)
+			}
+
+			var in = "$conf = ~/.config\n" +
+				"$nested = \"$conf/whatever\"  # comment\n" +
+				"c $conf/\n" +
+				"cw ${nested}/conf\n" +
+				"e ~me/${BM_TEST_CONFIG}/\n" +
+				"d ${BM_TEST_UNSET:-.config}/\n" +
+				"!echo echo $conf\n" +
+				"q '$conf'/\n" +
+				"u $undefined/x\n" +
+				"$bad = two words\n" +
+				"n ~nobody/x"
+			var missingFlags = flags
+			missingFlags.missingPolicy = "warn"
+			var out, errs = parseFile(in, missingFlags)
+			g.Assert(out).Equal([]Bookmark{
+				{typ: "dir", path: addHome(".config") + "/", abbreviation: "c"},
+				{typ: "file", path: addHome(".config/whatever/conf"), abbreviation: "cw"},
+				{typ: "dir", path: addHome(".config") + "/", abbreviation: "e"},
+				{typ: "dir", path: ".config/", abbreviation: "d"},
+				{typ: "shell", path: "echo $conf", abbreviation: "echo"},
+				{typ: "dir", path: "$conf/", abbreviation: "q"},
+			})
+			g.Assert(errs).Equal(ParseErrors{
+				{File: "list", Line: 8, Column: 3, Severity: SeverityWarning, Message: "filepath $conf/ does not exist"},
+				{File: "list", Line: 9, Column: 3, Severity: SeverityError, Message: "variable undefined is not defined"},
+				{File: "list", Line: 10, Column: 12, Severity: SeverityError, Message: "the value of $bad is more than one word, quote it if it has spaces"},
+				{File: "list", Line: 11, Column: 3, Severity: SeverityError, Message: "unknown user nobody"},
+			})
+		})
 	})
 
+	g.Describe("include", func() {
+		var flags = Flags{homePath: "/home/me", bookmarkFile: "/home/me/.config/bookmarker/list", missingPolicy: "error"}
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+			AppFs.MkdirAll("/home/me/.config/bookmarker/team", 0755)
+			AppFs.MkdirAll("/srv", 0755)
+		})
+
+		g.It("parses included files where the include is", func() {
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/team/list", []byte("$srv = /srv\ns $srv/\ninclude? ../missing\n"), 0644)
+			var in = "c .config/\ninclude team/list\nt $srv/\ninclude? nowhere\n"
+			var out, errs, inputs = parseBookmarkFiles(in, flags)
+			g.Assert(errs).IsNil()
+			g.Assert(inputs.Includes).Equal([]string{"/home/me/.config/bookmarker/team/list"})
+			g.Assert(len(out)).Equal(3)
+			g.Assert(out[1].Bookmark).Equal(Bookmark{typ: "dir", path: "/srv/", abbreviation: "s"})
+			g.Assert(out[1].position()).Equal("/home/me/.config/bookmarker/team/list:2")
+			g.Assert(out[1].includedFrom).Equal([]string{"/home/me/.config/bookmarker/list:2"})
+			// variables of an included file are there after the include
+			g.Assert(out[2].Bookmark).Equal(Bookmark{typ: "dir", path: "/srv/", abbreviation: "t"})
+		})
+
+		g.It("reports problems with the include chain", func() {
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/team/list", []byte("include inner\n"), 0644)
+			afero.WriteFile(AppFs, "/home/me/.config/bookmarker/team/inner", []byte("x gone/\ninclude ../list\n"), 0644)
+			var _, errs = parseFile("include team/list\ninclude absent\n", flags)
+			g.Assert(len(errs)).Equal(3)
+			g.Assert(errs[0].Error()).Equal("/home/me/.config/bookmarker/team/inner:1:3: filepath gone/ does not exist " +
+				"(included from /home/me/.config/bookmarker/team/list:1, /home/me/.config/bookmarker/list:1)")
+			g.Assert(errs[1].Message).Equal("include cycle: /home/me/.config/bookmarker/list -> " +
+				"/home/me/.config/bookmarker/team/list -> /home/me/.config/bookmarker/team/inner -> /home/me/.config/bookmarker/list")
+			g.Assert(errs[2].IncludedFrom == nil).IsTrue()
+			g.Assert(errs[2].Line).Equal(2)
+		})
+	})
 }
@@ -11,6 +11,11 @@ import (
 	"github.com/spf13/afero"
 )
 
+// what a file looks like when it only has the generated section
+func inManagedBlock(lines string) string {
+	return START_GENERATION_STRING + "\n\n" + lines + "\n" + END_GENERATION_STRING + "\n"
+}
+
 func TestShellAlias(t *testing.T) {
 	var g = Goblin(t)
 	AppFs = afero.NewMemMapFs()
@@ -18,9 +23,11 @@ func TestShellAlias(t *testing.T) {
 	g.Describe("generate correct shell aliases", func() {
 		var homeDir, _ = os.UserHomeDir()
 		var flags = Flags{
-			homePath:       homeDir,
-			editor:         "vim",
-			shellAliasFile: path.Join(homeDir, ".config", "shell", "aliasrc"),
+			homePath:               homeDir,
+			editor:                 "vim",
+			shellAliasFile:         path.Join(homeDir, ".config", "shell", "aliasrc"),
+			shellAliasFolderPrefix: "c",
+			shellAliasFilePrefix:   "cf",
 		}
 		g.Before(func() {
 
@@ -54,7 +61,7 @@ func TestShellAlias(t *testing.T) {
 				fmt.Sprintf("alias cfwws='vim %s/deeply/nested/path/works/fine.conf'", flags.homePath),
 				"alias cfabs='vim /absolute/path/to/nowhere.ini'",
 			}
-			var want = strings.Join(strs, "\n") + "\n"
+			var want = inManagedBlock(strings.Join(strs, "\n") + "\n")
 			generateShellAliases(bookmarks, flags)
 			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
 			g.Assert(text).Equal(want)
@@ -83,7 +90,7 @@ func TestShellAlias(t *testing.T) {
 				fmt.Sprintf("alias cwws='cd %s/deeply/nested/path/works/fine'", flags.homePath),
 				"alias cabs='cd /absolute/path/to/nowhere'",
 			}
-			var want = strings.Join(strs, "\n") + "\n"
+			var want = inManagedBlock(strings.Join(strs, "\n") + "\n")
 			generateShellAliases(bookmarks, flags)
 			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
 			g.Assert(text).Equal(want)
@@ -106,11 +113,136 @@ func TestShellAlias(t *testing.T) {
 				"alias update='sudo apt update && sudo apt upgrade'",
 				"alias ls='exa -la'",
 			}
-			var want = strings.Join(strs, "\n") + "\n"
+			var want = inManagedBlock(strings.Join(strs, "\n") + "\n")
 			generateShellAliases(bookmarks, flags)
 			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
 			g.Assert(text).Equal(want)
 		})
 
+		g.It("quotes hostile filenames", func() {
+			var bookmarks = []Bookmark{
+				{
+					typ:          "dir",
+					path:         "/tmp/it's $HOME; rm -rf x",
+					abbreviation: "q",
+				},
+				{
+					typ:          "file",
+					path:         "/tmp/a`b` & c.txt",
+					abbreviation: "b",
+				},
+				{
+					typ:          "dir",
+					path:         "My Documents/",
+					abbreviation: "d",
+				},
+				{
+					typ:          "shell",
+					path:         "echo 'hello world'",
+					abbreviation: "h",
+				},
+			}
+			var strs = []string{
+				`alias cq='cd '\''/tmp/it'\''\'\'''\''s $HOME; rm -rf x'\'''`,
+				"alias cfb='vim '\\''/tmp/a`b` & c.txt'\\'''",
+				fmt.Sprintf(`alias cd='cd '\''%s/My Documents'\'''`, flags.homePath),
+				`alias h='echo '\''hello world'\'''`,
+			}
+			var want = inManagedBlock(strings.Join(strs, "\n") + "\n")
+			generateShellAliases(bookmarks, flags)
+			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
+			g.Assert(text).Equal(want)
+		})
+
+		g.It("quotes every word of the editor command", func() {
+			var editorFlags = flags
+			editorFlags.editor = `"/opt/Sublime Text/subl" --wait`
+			var bookmarks = []Bookmark{
+				{
+					typ:          "file",
+					path:         "/etc/hosts",
+					abbreviation: "h",
+				},
+			}
+			generateShellAliases(bookmarks, editorFlags)
+			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
+			g.Assert(text).Equal(inManagedBlock(`alias cfh=''\''/opt/Sublime Text/subl'\'' --wait /etc/hosts'` + "\n"))
+		})
+
+		g.It("generates functions that take a subpath", func() {
+			var functionFlags = flags
+			functionFlags.shellFunctions = true
+			var bookmarks = []Bookmark{
+				{
+					typ:          "dir",
+					path:         "/my docs/",
+					abbreviation: "m",
+				},
+				{
+					typ:          "file",
+					path:         "/etc/hosts",
+					abbreviation: "h",
+				},
+				{
+					typ:          "shell",
+					path:         "exa -la",
+					abbreviation: "ls",
+				},
+			}
+			var lines, err = renderShellAliases(bookmarks, functionFlags)
+			g.Assert(err).IsNil()
+			var want = "cm() { builtin cd '/my docs'/\"${1-}\"; }\n" +
+				"cfh() { vim /etc/hosts \"$@\"; }\n" +
+				"alias ls='exa -la'\n" +
+				renderSubpathCompletion([]string{"cm"}, []string{"/my docs"})
+			g.Assert(lines).Equal(want)
+			g.Assert(strings.HasPrefix(renderSubpathCompletion([]string{"cm"}, []string{"/my docs"}), "_bm_base() {\n  case $1 in\n    cm) printf '%s\\n' '/my docs' ;;\n")).IsTrue()
+
+			// cd() would replace cd itself
+			_, err = renderShellAliases([]Bookmark{{typ: "dir", path: "/tmp", abbreviation: "d"}}, functionFlags)
+			g.Assert(err.Error()).Equal("cannot create a shell function for d: cd is a shell builtin")
+		})
+
+		g.It("exports cdable variables for folders", func() {
+			var cdableFlags = flags
+			cdableFlags.bashCdableVars = true
+			cdableFlags.bashVariablePrefix = "bm_"
+			var bookmarks = []Bookmark{
+				{
+					typ:          "dir",
+					path:         "/my docs",
+					abbreviation: "a-b",
+				},
+				{
+					typ:          "dir",
+					path:         "/tmp",
+					abbreviation: "a.b",
+				},
+				{
+					typ:          "file",
+					path:         "/etc/hosts",
+					abbreviation: "h",
+				},
+			}
+			var vars, err = renderBashCdableVars(bookmarks, cdableFlags)
+			g.Assert(err).IsNil()
+			g.Assert(strings.HasPrefix(vars, "export bm_a_b='/my docs'\nif [ -n \"${BASH_VERSION-}\" ]; then\n  shopt -s cdable_vars\n")).IsTrue()
+			g.Assert(strings.Contains(vars, "compgen -W 'bm_a_b' -S /")).IsTrue()
+
+			generateShellAliases(bookmarks, cdableFlags)
+			var text, _, _ = readTextFromFile(path.Join(flags.homePath, ".config", "shell", "aliasrc"))
+			g.Assert(strings.Contains(text, "alias cfh='vim /etc/hosts'\n"+vars)).IsTrue()
+
+			cdableFlags.bashVariablePrefix = "1x"
+			_, err = renderBashCdableVars(bookmarks, cdableFlags)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("rejects abbreviations that are not valid alias names", func() {
+			for _, abbreviation := range []string{"a;b", "x'", "$(id)", "a b", "!x"} {
+				var err = generateShellAliases([]Bookmark{{typ: "shell", path: "ls", abbreviation: abbreviation}}, flags)
+				g.Assert(err == nil).IsFalse()
+			}
+		})
 	})
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// characters that mean nothing special to a POSIX shell
+const shellSafeCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+// wraps the text in single quotes, a single quote inside is ended, escaped and started again
+// the shell will read the result back as exactly the same text, nothing gets expanded
+var singleQuote = func(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// quotes a word only if the shell would do something with it otherwise
+// so normal paths stay readable in the generated files
+var shellQuote = func(word string) string {
+	if len(word) == 0 {
+		return "''"
+	}
+	for _, char := range word {
+		if !strings.ContainsRune(shellSafeCharacters, char) {
+			return singleQuote(word)
+		}
+	}
+	return word
+}
+
+// the portable alias name characters, without ! which bash uses for history
+const aliasNameCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_.-%,@"
+
+// builtins and keywords of sh, bash and zsh, an alias with one of these names hides it
+var shellBuiltins = []string{
+	".", ":", "[", "alias", "bg", "bind", "break", "builtin", "case", "cd", "command", "continue",
+	"declare", "dirs", "disown", "do", "done", "echo", "elif", "else", "enable", "esac", "eval",
+	"exec", "exit", "export", "false", "fc", "fg", "fi", "for", "function", "getopts", "hash",
+	"help", "history", "if", "jobs", "kill", "let", "local", "logout", "popd", "printf", "pushd",
+	"pwd", "read", "readonly", "return", "select", "set", "shift", "source", "suspend", "test",
+	"then", "time", "times", "trap", "true", "type", "typeset", "ulimit", "umask", "unalias",
+	"unset", "until", "wait", "while",
+}
+
+var checkAliasName = func(name string) error {
+	if len(name) == 0 {
+		return errors.New("alias name is empty")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("alias name %s starts with -", name)
+	}
+	for _, char := range name {
+		if !strings.ContainsRune(aliasNameCharacters, char) {
+			return fmt.Errorf("alias name %s contains %q, only letters, digits and %s are allowed", name, char, "_.-%,@")
+		}
+	}
+	return nil
+}
+
+// the editor can be a command with arguments (code --wait) so it is split like a bookmark line
+// and every word is quoted again
+var quoteEditorCommand = func(editor string) (string, error) {
+	var tokens, lexErr = lexLine(editor)
+	if lexErr != nil {
+		return "", fmt.Errorf("cannot read editor command %s: %s", editor, lexErr.Error())
+	}
+	if len(tokens) == 0 {
+		return "", errors.New("editor command is empty")
+	}
+	var words = make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		words = append(words, shellQuote(tok.text))
+	}
+	return strings.Join(words, " "), nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// one line of the edit script, kind is ' ', '-' or '+'
+type diffLine struct {
+	kind    byte
+	text    string
+	oldLine int
+	newLine int
+}
+
+// what diff -u prints after a last line without a newline
+const noNewlineMarker = "\n\\ No newline at end of file"
+
+// a last line without a newline carries the marker, so it differs from the same line with one
+var splitLines = func(text string) []string {
+	if len(text) == 0 {
+		return []string{}
+	}
+	var lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += noNewlineMarker
+	}
+	return lines
+}
+
+// the generated files are small, so the plain longest common subsequence table is good enough
+var diffLines = func(oldLines []string, newLines []string) []diffLine {
+	var common = make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var script = []diffLine{}
+	var i, j = 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		if i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j] {
+			script = append(script, diffLine{kind: ' ', text: oldLines[i], oldLine: i, newLine: j})
+			i++
+			j++
+		} else if j == len(newLines) || (i < len(oldLines) && common[i+1][j] >= common[i][j+1]) {
+			script = append(script, diffLine{kind: '-', text: oldLines[i], oldLine: i, newLine: j})
+			i++
+		} else {
+			script = append(script, diffLine{kind: '+', text: newLines[j], oldLine: i, newLine: j})
+			j++
+		}
+	}
+	return script
+}
+
+// the start and the length of a hunk, in the format of the @@ line
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// the diff in the same format as diff -u, an empty string means the texts are the same
+var unifiedDiff = func(oldName string, newName string, oldText string, newText string, color bool) string {
+	var paint = func(code string, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + colorReset
+	}
+
+	var script = diffLines(splitLines(oldText), splitLines(newText))
+	var changes = []int{}
+	for index, line := range script {
+		if line.kind != ' ' {
+			changes = append(changes, index)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+	output.WriteString(paint(colorBold, "--- "+oldName) + "\n")
+	output.WriteString(paint(colorBold, "+++ "+newName) + "\n")
+
+	// changes that are close to each other share a hunk
+	var index = 0
+	for index < len(changes) {
+		var first = changes[index]
+		var last = first
+		for index+1 < len(changes) && changes[index+1]-last <= 2*diffContextLines {
+			index++
+			last = changes[index]
+		}
+		index++
+
+		var start = first - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		var end = last + diffContextLines + 1
+		if end > len(script) {
+			end = len(script)
+		}
+
+		var oldLength, newLength = 0, 0
+		for _, line := range script[start:end] {
+			if line.kind != '+' {
+				oldLength++
+			}
+			if line.kind != '-' {
+				newLength++
+			}
+		}
+		output.WriteString(paint(colorCyan, fmt.Sprintf("@@ -%s +%s @@", hunkRange(script[start].oldLine, oldLength), hunkRange(script[start].newLine, newLength))) + "\n")
+
+		for _, line := range script[start:end] {
+			switch line.kind {
+			case '-':
+				output.WriteString(paint(colorRed, "-"+line.text) + "\n")
+			case '+':
+				output.WriteString(paint(colorGreen, "+"+line.text) + "\n")
+			default:
+				output.WriteString(" " + line.text + "\n")
+			}
+		}
+	}
+	return output.String()
+}
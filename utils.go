@@ -12,11 +12,12 @@ import (
 	"github.com/spf13/afero"
 )
 
+// the trailing / is left out, a file/ is not a directory to stat but it should still be found as a file
 var obtainPathInfo = func(filepath string, flags Flags) (fs.FileInfo, error) {
 	var finalPath string
 	// if it is an absolute path
 	if strings.HasPrefix(filepath, "/") {
-		finalPath = filepath
+		finalPath = path.Clean(filepath)
 	} else {
 		var homePath, err = getHomePath(flags)
 		if err != nil {
@@ -27,9 +28,10 @@ var obtainPathInfo = func(filepath string, flags Flags) (fs.FileInfo, error) {
 	return AppFs.Stat(finalPath)
 }
 
+// errors go to stderr, so they do not end up in $(bm path ...) or eval "$(bm init ...)"
 var exitIf = func(err error) {
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 }
@@ -58,9 +60,10 @@ var checkHomePath = func(flags Flags) error {
 	return nil
 }
 
+// the absolute path, cleaned like path.Join cleans the relative ones, so folders have no trailing /
 var resolve = func(filepath string, flags Flags) string {
 	if strings.HasPrefix(filepath, "/") {
-		return filepath
+		return path.Clean(filepath)
 	} else {
 		return path.Join(flags.homePath, filepath)
 	}
@@ -74,3 +77,27 @@ var readTextFromFile = func(filepath string) (string, afero.File, error) {
 	var text, readErr = ioutil.ReadAll(file)
 	return string(text), file, readErr
 }
+
+var contains = func(list []string, item string) bool {
+	for _, element := range list {
+		if element == item {
+			return true
+		}
+	}
+	return false
+}
+
+// warnings do not stop anything, but the user should still see them
+var printParseWarnings = func(errs ParseErrors) {
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+}
+
+var checkMissingPolicy = func(flags Flags) error {
+	switch flags.missingPolicy {
+	case "error", "warn", "skip":
+		return nil
+	}
+	return fmt.Errorf("--missing must be error, warn or skip, not %s", flags.missingPolicy)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path"
+)
+
+/*
+where files go, following the XDG base directory specification:
+config for the bookmark file and the files of other programs, data for what programs keep (like lf marks),
+state for what we remember between runs and cache for what can be thrown away
+*/
+type xdgDirectories struct {
+	configHome string
+	dataHome   string
+	stateHome  string
+	cacheHome  string
+}
+
+// the spec says to ignore a variable that is empty or not an absolute path
+var xdgDirectory = func(variable string, fallback string) string {
+	var dir = os.Getenv(variable)
+	if !path.IsAbs(dir) {
+		return fallback
+	}
+	return dir
+}
+
+var xdgBaseDirectories = func(homePath string) xdgDirectories {
+	return xdgDirectories{
+		configHome: xdgDirectory("XDG_CONFIG_HOME", path.Join(homePath, ".config")),
+		dataHome:   xdgDirectory("XDG_DATA_HOME", path.Join(homePath, ".local", "share")),
+		stateHome:  xdgDirectory("XDG_STATE_HOME", path.Join(homePath, ".local", "state")),
+		cacheHome:  xdgDirectory("XDG_CACHE_HOME", path.Join(homePath, ".cache")),
+	}
+}
+
+func (x xdgDirectories) configFile(parts ...string) string {
+	return path.Join(append([]string{x.configHome}, parts...)...)
+}
+
+func (x xdgDirectories) dataFile(parts ...string) string {
+	return path.Join(append([]string{x.dataHome}, parts...)...)
+}
+
+func (x xdgDirectories) stateFile(parts ...string) string {
+	return path.Join(append([]string{x.stateHome}, parts...)...)
+}
+
+func (x xdgDirectories) cacheFile(parts ...string) string {
+	return path.Join(append([]string{x.cacheHome}, parts...)...)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestXdg(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("XDG base directories", func() {
+		g.It("falls back to the folders in home", func() {
+			for _, variable := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME"} {
+				t.Setenv(variable, "")
+			}
+			g.Assert(xdgBaseDirectories("/home/me")).Equal(xdgDirectories{
+				configHome: "/home/me/.config",
+				dataHome:   "/home/me/.local/share",
+				stateHome:  "/home/me/.local/state",
+				cacheHome:  "/home/me/.cache",
+			})
+		})
+
+		g.It("follows the variables and ignores relative ones", func() {
+			t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+			t.Setenv("XDG_DATA_HOME", "relative/data")
+			t.Setenv("XDG_STATE_HOME", "/xdg/state")
+			t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+			var xdg = xdgBaseDirectories("/home/me")
+			g.Assert(xdg.configFile("lf", "lfrc")).Equal("/xdg/config/lf/lfrc")
+			g.Assert(xdg.dataFile("lf", "marks")).Equal("/home/me/.local/share/lf/marks")
+		})
+
+		g.It("keeps state and cache files in a custom layout", func() {
+			t.Setenv("XDG_STATE_HOME", "/xdg/state")
+			t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+			AppFs = afero.NewMemMapFs()
+			var flags = Flags{
+				homePath:               "/home/me",
+				bookmarkFile:           "/xdg/config/bookmarker/list",
+				lfMarksFile:            "/xdg/data/lf/marks",
+				shellAliasFolderPrefix: "cd",
+				editor:                 "vim",
+				missingPolicy:          "warn",
+				initCache:              true,
+			}
+			afero.WriteFile(AppFs, flags.bookmarkFile, []byte("t /tmp/\n"), 0644)
+
+			g.Assert(generateLfMarks([]Bookmark{{typ: "dir", path: "/tmp", abbreviation: "t"}}, flags)).IsNil()
+			var state, _ = afero.ReadFile(AppFs, "/xdg/state/bookmarker/lf-marks")
+			g.Assert(string(state)).Equal("t:/tmp\n")
+
+			var code, err = renderInit("bash", flags)
+			g.Assert(err).IsNil()
+			var cached, _ = afero.ReadFile(AppFs, "/xdg/cache/bookmarker/init.bash")
+			g.Assert(len(code) != 0 && len(cached) > len(code)).IsTrue()
+			var exists, _ = afero.Exists(AppFs, "/home/me/.cache")
+			g.Assert(exists).IsFalse()
+		})
+	})
+}
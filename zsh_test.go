@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/spf13/afero"
+)
+
+func TestZsh(t *testing.T) {
+	var g = Goblin(t)
+
+	g.Describe("generate correct zsh bookmarks", func() {
+		var homeDir, _ = os.UserHomeDir()
+		var flags = Flags{
+			homePath:             homeDir,
+			editor:               "vim",
+			zshFile:              path.Join(homeDir, ".config", "zsh", "bookmarker.zsh"),
+			shellAliasFilePrefix: "cf",
+		}
+		var bookmarks = []Bookmark{
+			{
+				typ:          "dir",
+				path:         ".config/",
+				abbreviation: "c",
+			},
+			{
+				typ:          "dir",
+				path:         "/my docs",
+				abbreviation: "d",
+			},
+			{
+				typ:          "file",
+				path:         "/etc/hosts",
+				abbreviation: "h",
+			},
+			{
+				typ:          "shell",
+				path:         "exa -la",
+				abbreviation: "ls",
+			},
+		}
+		var want = fmt.Sprintf("hash -d c=%s/.config\n", homeDir) +
+			"hash -d d='/my docs'\n" +
+			"alias cfh='vim /etc/hosts'\n" +
+			"alias ls='exa -la'\n" +
+			"_bm_named_directories() {\n" +
+			"  local -a bookmarks\n" +
+			"  bookmarks=(\n" +
+			fmt.Sprintf("    'c:%s/.config'\n", homeDir) +
+			"    'd:/my docs'\n" +
+			"  )\n" +
+			"  _describe -t named-directories 'bookmark' bookmarks -S / || _tilde \"$@\"\n" +
+			"}\n" +
+			"(( $+functions[compdef] )) && compdef _bm_named_directories -tilde-\n"
+
+		g.BeforeEach(func() {
+			AppFs = afero.NewMemMapFs()
+		})
+
+		g.It("writes into a managed block", func() {
+			afero.WriteFile(AppFs, flags.zshFile, []byte("setopt autocd\n"), 0644)
+			g.Assert(generateZsh(bookmarks, flags)).IsNil()
+			var text, _, _ = readTextFromFile(flags.zshFile)
+			g.Assert(text).Equal("setopt autocd\n" + inManagedBlock(want))
+		})
+
+		g.It("owns the whole file when standalone", func() {
+			var standaloneFlags = flags
+			standaloneFlags.zshStandalone = true
+			afero.WriteFile(AppFs, flags.zshFile, []byte("old content\n"), 0644)
+			g.Assert(generateZsh(bookmarks, standaloneFlags)).IsNil()
+			var text, _, _ = readTextFromFile(flags.zshFile)
+			g.Assert(text).Equal("# Generated by BOOKMARKER, changes to this file will be overwritten\n\n" + want)
+		})
+
+		g.It("rejects abbreviations that cannot be named directories", func() {
+			var err = generateZsh([]Bookmark{{typ: "dir", path: "/tmp", abbreviation: "a.b"}}, flags)
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}